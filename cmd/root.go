@@ -1,11 +1,15 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
 	"strings"
+	"text/tabwriter"
+	"time"
 
 	"github.com/alecthomas/kong"
 	"github.com/charmbracelet/log"
@@ -13,6 +17,8 @@ import (
 	"github.com/viniciusamelio/alfred/internal/context"
 	"github.com/viniciusamelio/alfred/internal/git"
 	"github.com/viniciusamelio/alfred/internal/pubspec"
+	"github.com/viniciusamelio/alfred/internal/scan"
+	"github.com/viniciusamelio/alfred/internal/status"
 	"github.com/viniciusamelio/alfred/internal/tui"
 	"github.com/viniciusamelio/alfred/internal/worktree"
 )
@@ -23,38 +29,541 @@ const (
 )
 
 var CLI struct {
-	Debug      bool          `help:"Enable debug mode" default:"false"`
-	Context    ContextCmd    `cmd:"" help:"Manage project contexts"`
-	Init       InitCmd       `cmd:"" help:"Initialize alfred in current directory"`
-	Scan       ScanCmd       `cmd:"" help:"Scan directory and auto-configure repositories"`
-	Status     StatusCmd     `cmd:"" help:"Show current context and repository status"`
-	List       ListCmd       `cmd:"" help:"List available contexts"`
-	Switch     SwitchCmd     `cmd:"" help:"Switch to a different context"`
-	Create     CreateCmd     `cmd:"" help:"Create a new context"`
-	Delete     DeleteCmd     `cmd:"" help:"Delete contexts"`
-	Prepare    PrepareCmd    `cmd:"" help:"Prepare repository for production by reverting to git dependencies"`
-	MainBranch MainBranchCmd `cmd:"" help:"Set the main branch used when switching to main context"`
-	Commit     CommitCmd     `cmd:"" help:"Interactive commit interface for all repositories in current context"`
-	Push       PushCmd       `cmd:"" help:"Push changes to remote for all repositories in current context"`
-	Pull       PullCmd       `cmd:"" help:"Pull changes from remote for all repositories in current context"`
-	Diagnose   DiagnoseCmd   `cmd:"" help:"Diagnose git status and upstream configuration for current context"`
-	Version    VersionCmd    `cmd:"" help:"Show version information"`
+	Debug        bool            `help:"Enable debug mode" default:"false"`
+	Context      ContextCmd      `cmd:"" help:"Manage project contexts"`
+	Init         InitCmd         `cmd:"" help:"Initialize alfred in current directory"`
+	Scan         ScanCmd         `cmd:"" help:"Scan directory and auto-configure repositories"`
+	Status       StatusCmd       `cmd:"" help:"Show current context and repository status"`
+	List         ListCmd         `cmd:"" help:"List available contexts"`
+	Switch       SwitchCmd       `cmd:"" help:"Switch to a different context"`
+	History      HistoryCmd      `cmd:"" help:"List recent context switches"`
+	Create       CreateCmd       `cmd:"" help:"Create a new context"`
+	Delete       DeleteCmd       `cmd:"" help:"Delete contexts"`
+	Prepare      PrepareCmd      `cmd:"" help:"Prepare repository for production by reverting to git dependencies"`
+	MainBranch   MainBranchCmd   `cmd:"" help:"Set the main branch used when switching to main context"`
+	Commit       CommitCmd       `cmd:"" help:"Interactive commit interface for all repositories in current context"`
+	Push         PushCmd         `cmd:"" help:"Push changes to remote for all repositories in current context"`
+	Pull         PullCmd         `cmd:"" help:"Pull changes from remote for all repositories in current context"`
+	Sync         SyncCmd         `cmd:"" help:"Rebase or merge all context branches onto the latest main branch"`
+	Prune        PruneCmd        `cmd:"" help:"Delete contexts that are merged or idle"`
+	FetchContext FetchContextCmd `cmd:"" name:"fetch-context" help:"Check out a context from the remote branches named after it"`
+	Bootstrap    BootstrapCmd    `cmd:"" help:"Clone the configured repositories missing from this workspace"`
+	Diagnose     DiagnoseCmd     `cmd:"" help:"Diagnose git status and upstream configuration for current context"`
+	Worktree     WorktreeCmd     `cmd:"" help:"Manage context worktrees"`
+	Config       ConfigCmd       `cmd:"" help:"Manage the alfred configuration"`
+	Version      VersionCmd      `cmd:"" help:"Show version information"`
 }
 
 type ContextCmd struct {
-	List   ListCmd   `cmd:"" help:"List available contexts"`
-	Switch SwitchCmd `cmd:"" help:"Switch to a context"`
-	Create CreateCmd `cmd:"" help:"Create a new context"`
-	Delete DeleteCmd `cmd:"" help:"Delete contexts"`
-	Scan   ScanCmd   `cmd:"" help:"Scan directory and auto-configure repositories"`
+	List       ListCmd              `cmd:"" help:"List available contexts"`
+	Switch     SwitchCmd            `cmd:"" help:"Switch to a context"`
+	Create     CreateCmd            `cmd:"" help:"Create a new context"`
+	Delete     DeleteCmd            `cmd:"" help:"Delete contexts"`
+	Rename     ContextRenameCmd     `cmd:"" help:"Rename a context with its branches, worktrees and stashes"`
+	AddRepo    ContextAddRepoCmd    `cmd:"" name:"add-repo" help:"Add repositories to an existing context"`
+	RemoveRepo ContextRemoveRepoCmd `cmd:"" name:"remove-repo" help:"Remove repositories from a context"`
+	Clone      ContextCloneCmd      `cmd:"" help:"Create a new context branched off an existing one"`
+	Adopt      ContextAdoptCmd      `cmd:"" help:"Register existing branches shared by several repositories as contexts"`
+	Share      ContextShareCmd      `cmd:"" help:"Move contexts into the team manifest (alfred.yaml) so they can be committed"`
+	Describe   ContextDescribeCmd   `cmd:"" help:"Show or change the description, ticket, owner and base of a context"`
+	Scan       ScanCmd              `cmd:"" help:"Scan directory and auto-configure repositories"`
 }
 
-type ScanCmd struct{}
+type ContextShareCmd struct {
+	Contexts []string `arg:"" help:"Contexts to share" optional:"true"`
+}
+
+func (c *ContextShareCmd) Run(ctx *kong.Context) error {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	created := !cfg.HasManifest()
+	if err := cfg.ShareContexts(c.Contexts); err != nil {
+		return err
+	}
+	if err := cfg.Save(); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+
+	if created {
+		fmt.Printf("✅ Created team manifest %s with the repositories and settings\n", config.ManifestFileName)
+	}
+	for _, name := range c.Contexts {
+		fmt.Printf("✅ Context '%s' is now shared\n", name)
+	}
+	fmt.Printf("Commit %s to share it with your team; .alfred/ keeps your personal contexts and local state.\n", config.ManifestFileName)
+	return nil
+}
+
+type ContextAdoptCmd struct {
+	Yes   bool `help:"Adopt every proposed context without asking" short:"y"`
+	Fetch bool `help:"Fetch remotes before scanning branches" default:"true" negatable:""`
+}
+
+func (c *ContextAdoptCmd) Run(ctx *kong.Context) error {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	manager := context.NewManager(cfg)
+	candidates, err := manager.FindAdoptCandidates(c.Fetch)
+	if err != nil {
+		return err
+	}
+
+	if len(candidates) == 0 {
+		fmt.Println("No branches shared by several repositories were found.")
+		return nil
+	}
+
+	var adopted []string
+	for _, candidate := range candidates {
+		fmt.Printf("🌿 %s: %s\n", candidate.Branch, strings.Join(candidate.Repos, ", "))
+		if len(candidate.RemoteOnly) > 0 {
+			fmt.Printf("   remote only in: %s\n", strings.Join(candidate.RemoteOnly, ", "))
+		}
+
+		contextName := candidate.Name
+		if !c.Yes {
+			fmt.Printf("   Adopt as context '%s'? (y/N, or type another name): ", contextName)
+			var response string
+			_, _ = fmt.Scanln(&response)
+			switch strings.ToLower(response) {
+			case "y", "yes":
+			case "", "n", "no":
+				continue
+			default:
+				contextName = response
+			}
+		}
+
+		if err := manager.AdoptContext(candidate, contextName); err != nil {
+			fmt.Printf("❌ %s: %v\n", candidate.Branch, err)
+			continue
+		}
+		adopted = append(adopted, contextName)
+	}
+
+	if len(adopted) == 0 {
+		fmt.Println("\nNo contexts adopted.")
+		return nil
+	}
+
+	fmt.Printf("\n✅ Adopted contexts: %s\n", strings.Join(adopted, ", "))
+	return nil
+}
+
+type ContextCloneCmd struct {
+	Src         string `arg:"" help:"Context to branch off"`
+	Dst         string `arg:"" help:"Name of the new context"`
+	WithChanges bool   `help:"Copy uncommitted changes of the source context" name:"with-changes"`
+}
+
+func (c *ContextCloneCmd) Run(ctx *kong.Context) error {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	manager := context.NewManager(cfg)
+	if err := manager.CloneContext(c.Src, c.Dst, context.CloneOptions{CopyChanges: c.WithChanges}); err != nil {
+		return fmt.Errorf("failed to clone context: %w", err)
+	}
+
+	fmt.Printf("✅ Created context '%s' from '%s'\n", c.Dst, c.Src)
+	fmt.Printf("   Run 'alfred switch %s' to start working on it\n", c.Dst)
+	return nil
+}
+
+type ContextAddRepoCmd struct {
+	Context string   `arg:"" help:"Context name"`
+	Repos   []string `arg:"" help:"Repository aliases or @groups to add" optional:"true"`
+}
+
+func (c *ContextAddRepoCmd) Run(ctx *kong.Context) error {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	if !cfg.ContextExists(c.Context) {
+		return fmt.Errorf("context '%s' not found", c.Context)
+	}
+
+	// Offer only the groups and repositories that are not in the context yet
+	var aliases, paths []string
+	refs, details := repoChoices(cfg)
+	for i, ref := range refs {
+		expanded, err := cfg.ExpandRepos([]string{ref})
+		if err != nil {
+			continue
+		}
+		for _, alias := range expanded {
+			if !cfg.ContextContainsRepo(c.Context, alias) {
+				aliases = append(aliases, ref)
+				paths = append(paths, details[i])
+				break
+			}
+		}
+	}
+
+	repos := c.Repos
+	if len(repos) == 0 {
+		if len(aliases) == 0 {
+			fmt.Printf("All repositories are already in context '%s'.\n", c.Context)
+			return nil
+		}
+
+		repos, err = tui.RunRepoSelectorWithTitle(fmt.Sprintf("Add repositories to '%s'", c.Context), aliases, paths)
+		if err != nil {
+			if strings.Contains(err.Error(), "TTY") || strings.Contains(err.Error(), "tty") {
+				fmt.Println("Repositories not in this context:")
+				for _, alias := range aliases {
+					fmt.Printf("  %s\n", alias)
+				}
+				fmt.Println("\nUsage: alfred context add-repo <context> <repo> [<repo>...]")
+				return nil
+			}
+			return err
+		}
+	}
+
+	manager := context.NewManager(cfg)
+	for _, ref := range repos {
+		// A group adds the repositories of the group missing from the context
+		added := []string{ref}
+		if config.IsGroupRef(ref) {
+			expanded, err := cfg.ExpandRepos(added)
+			if err != nil {
+				return err
+			}
+			added = nil
+			for _, alias := range expanded {
+				if !cfg.ContextContainsRepo(c.Context, alias) {
+					added = append(added, alias)
+				}
+			}
+		}
+
+		for _, alias := range added {
+			if err := manager.AddRepoToContext(c.Context, alias); err != nil {
+				return fmt.Errorf("failed to add %s: %w", alias, err)
+			}
+			fmt.Printf("✅ Added %s to context '%s'\n", alias, c.Context)
+		}
+	}
+	return nil
+}
+
+type ContextRemoveRepoCmd struct {
+	Context string   `arg:"" help:"Context name"`
+	Repos   []string `arg:"" help:"Repository aliases or @groups to remove" optional:"true"`
+	Force   bool     `help:"Remove even if uncommitted changes, unpushed commits or stashes would be lost" short:"f"`
+	Backup  bool     `help:"Keep the branch tip and uncommitted changes under refs/alfred/trash/"`
+}
+
+func (c *ContextRemoveRepoCmd) Run(ctx *kong.Context) error {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	if !cfg.ContextExists(c.Context) {
+		return fmt.Errorf("context '%s' not found", c.Context)
+	}
+
+	repos := c.Repos
+	if len(repos) == 0 {
+		var paths []string
+		aliases := cfg.GetContextAliases(c.Context)
+		for _, alias := range aliases {
+			if repo, err := cfg.GetRepoByAlias(alias); err == nil {
+				paths = append(paths, repo.Path)
+			} else {
+				paths = append(paths, "")
+			}
+		}
+
+		repos, err = tui.RunRepoSelectorWithTitle(fmt.Sprintf("Remove repositories from '%s'", c.Context), aliases, paths)
+		if err != nil {
+			if strings.Contains(err.Error(), "TTY") || strings.Contains(err.Error(), "tty") {
+				fmt.Printf("Repositories in context '%s':\n", c.Context)
+				for _, alias := range aliases {
+					fmt.Printf("  %s\n", alias)
+				}
+				fmt.Println("\nUsage: alfred context remove-repo <context> <repo> [<repo>...]")
+				return nil
+			}
+			return err
+		}
+	}
+
+	repos, err = cfg.ExpandRepos(repos)
+	if err != nil {
+		return err
+	}
+
+	manager := context.NewManager(cfg)
+	risk, err := manager.CheckDeleteSafety(c.Context)
+	if err != nil {
+		return err
+	}
+
+	for _, alias := range repos {
+		if !cfg.ContextContainsRepo(c.Context, alias) {
+			return fmt.Errorf("repository '%s' is not in context '%s'", alias, c.Context)
+		}
+
+		for _, repoRisk := range risk.Repos {
+			if repoRisk.Name != alias || c.Force || !repoRisk.HasRisk() {
+				continue
+			}
+
+			fmt.Printf("⚠️  Removing %s would lose %s\n", alias, strings.Join(repoRisk.Describe(), ", "))
+			fmt.Print("   Remove it anyway? (y/N): ")
+			var response string
+			_, _ = fmt.Scanln(&response)
+			if strings.ToLower(response) != "y" && strings.ToLower(response) != "yes" {
+				return fmt.Errorf("removal of %s %s", alias, canceledMessage)
+			}
+		}
+
+		if err := manager.RemoveRepoFromContext(c.Context, alias, context.DeleteOptions{Backup: c.Backup}); err != nil {
+			return fmt.Errorf("failed to remove %s: %w", alias, err)
+		}
+		fmt.Printf("✅ Removed %s from context '%s'\n", alias, c.Context)
+	}
+	return nil
+}
+
+type ContextRenameCmd struct {
+	Old    string `arg:"" help:"Current context name"`
+	New    string `arg:"" help:"New context name"`
+	Remote bool   `help:"Also rename the branches on the push remote"`
+}
+
+func (c *ContextRenameCmd) Run(ctx *kong.Context) error {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	if !cfg.ContextExists(c.Old) {
+		return fmt.Errorf("context '%s' not found", c.Old)
+	}
+	if cfg.ContextExists(c.New) {
+		return fmt.Errorf("context '%s' already exists", c.New)
+	}
+
+	manager := context.NewManager(cfg)
+	if err := manager.RenameContext(c.Old, c.New, context.RenameOptions{Remote: c.Remote}); err != nil {
+		return fmt.Errorf("failed to rename context: %w", err)
+	}
+
+	fmt.Printf("✅ Renamed context '%s' to '%s'\n", c.Old, c.New)
+	return nil
+}
+
+type ContextDescribeCmd struct {
+	Context string `arg:"" help:"Context name"`
+	ContextInfoFlags
+}
+
+func (c *ContextDescribeCmd) Run(ctx *kong.Context) error {
+	if err := c.validate(); err != nil {
+		return err
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	if !cfg.ContextExists(c.Context) {
+		return fmt.Errorf("context '%s' not found", c.Context)
+	}
+
+	if !c.isSet() {
+		info := cfg.GetContextInfo(c.Context)
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintf(w, "Context:\t%s\n", c.Context)
+		_, _ = fmt.Fprintf(w, "Branch:\t%s\n", cfg.GetBranchName(c.Context))
+		mode := cfg.GetContextMode(c.Context)
+		if info.Mode == "" {
+			mode += " (global)"
+		}
+		_, _ = fmt.Fprintf(w, "Mode:\t%s\n", mode)
+		for _, field := range []struct{ name, value string }{
+			{"Description", info.Description},
+			{"Ticket", info.Ticket},
+			{"Owner", info.Owner},
+			{"Base", info.Base},
+		} {
+			if field.value != "" {
+				_, _ = fmt.Fprintf(w, "%s:\t%s\n", field.name, field.value)
+			}
+		}
+		if !info.Created.IsZero() {
+			_, _ = fmt.Fprintf(w, "Created:\t%s (%s)\n", info.Created.Local().Format("2006-01-02 15:04"), formatAgo(info.Created))
+		}
+		if !info.LastSwitched.IsZero() {
+			_, _ = fmt.Fprintf(w, "Last switched:\t%s (%s)\n", info.LastSwitched.Local().Format("2006-01-02 15:04"), formatAgo(info.LastSwitched))
+		}
+		return w.Flush()
+	}
+
+	// The branch template may use the metadata: keep the branches the
+	// context already has instead of switching to new names
+	branch, mode := cfg.GetBranchName(c.Context), cfg.GetContextMode(c.Context)
+	cfg.SetContextInfo(c.Context, c.apply(cfg.GetContextInfo(c.Context)))
+
+	// The active context is moved to its new layout by switching to it
+	if currentContext, _ := context.NewManager(cfg).GetCurrentContext(); currentContext == c.Context && cfg.GetContextMode(c.Context) != mode {
+		return fmt.Errorf("cannot change the mode of the current context, switch to another context first")
+	}
+
+	pinned := cfg.GetBranchName(c.Context) != branch
+	if pinned {
+		cfg.SetContextBranch(c.Context, branch)
+	}
+
+	if err := cfg.Save(); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+
+	fmt.Printf("✅ Updated context '%s'\n", c.Context)
+	if pinned {
+		fmt.Printf("   Kept its branch '%s' in context_branches\n", branch)
+	}
+	return nil
+}
+
+type ConfigCmd struct {
+	Edit     ConfigEditCmd     `cmd:"" help:"Edit repositories, settings and contexts interactively"`
+	Validate ConfigValidateCmd `cmd:"" help:"Check the configuration and the repositories it points to"`
+	Schema   ConfigSchemaCmd   `cmd:"" help:"Print the JSON Schema of alfred.yaml for editor support"`
+	Migrate  ConfigMigrateCmd  `cmd:"" help:"Upgrade the configuration files to the current format, keeping a backup"`
+}
+
+type ConfigMigrateCmd struct {
+	DryRun bool `help:"Show the changes without writing them"`
+}
+
+func (c *ConfigMigrateCmd) Run(ctx *kong.Context) error {
+	migrations, err := config.PlanMigrations()
+	if err != nil {
+		return err
+	}
+
+	if len(migrations) == 0 {
+		fmt.Printf("✅ Configuration is up to date (version %d)\n", config.CurrentVersion)
+		return nil
+	}
+
+	for _, migration := range migrations {
+		if migration.From == config.CurrentVersion {
+			fmt.Printf("📦 %s: version %d\n", migration.File, migration.From)
+		} else {
+			fmt.Printf("📦 %s: version %d → %d\n", migration.File, migration.From, config.CurrentVersion)
+		}
+		for _, step := range migration.Steps {
+			fmt.Printf("   • %s\n", step)
+		}
+
+		if c.DryRun {
+			fmt.Println()
+			fmt.Print(migration.Diff())
+			fmt.Println()
+			continue
+		}
+
+		if err := migration.Apply(); err != nil {
+			return err
+		}
+		fmt.Printf("✅ Migrated %s (backup in %s)\n", migration.File, migration.Backup)
+	}
+	return nil
+}
+
+type ConfigValidateCmd struct{}
+
+func (c *ConfigValidateCmd) Run(ctx *kong.Context) error {
+	cfg, err := config.ReadConfig()
+	if err != nil {
+		return err
+	}
+
+	problems := append(cfg.Validate(), context.NewManager(cfg).CheckWorkspace()...)
+	if len(problems) == 0 {
+		fmt.Println("✅ Configuration is valid")
+		return nil
+	}
+
+	for _, problem := range cfg.Locate(problems) {
+		if problem.Warning {
+			fmt.Printf("⚠️  %s\n", problem.Error())
+		} else {
+			fmt.Printf("❌ %s\n", problem.Error())
+		}
+	}
+	if len(problems) == 1 {
+		return fmt.Errorf("1 problem found")
+	}
+	return fmt.Errorf("%d problems found", len(problems))
+}
+
+type ConfigSchemaCmd struct{}
+
+func (c *ConfigSchemaCmd) Run(ctx *kong.Context) error {
+	_, err := os.Stdout.Write(config.Schema)
+	return err
+}
+
+type ConfigEditCmd struct{}
+
+func (c *ConfigEditCmd) Run(ctx *kong.Context) error {
+	cfg, err := config.ReadConfig()
+	if err != nil {
+		return err
+	}
+
+	saved, err := tui.RunConfigEditor(cfg)
+	if err != nil {
+		if strings.Contains(err.Error(), "TTY") || strings.Contains(err.Error(), "tty") {
+			return fmt.Errorf("the configuration editor needs an interactive terminal; edit .alfred/alfred.yaml instead")
+		}
+		return err
+	}
+
+	if saved {
+		fmt.Println("✅ Configuration saved")
+	} else {
+		fmt.Println("Configuration left unchanged")
+	}
+	return nil
+}
+
+type ScanCmd struct {
+	Depth  int      `help:"How many directory levels to search for packages" default:"1"`
+	Ignore []string `help:"Glob of directories to skip, matched against the relative path or the directory name (repeatable)"`
+	Merge  bool     `help:"Add new repositories and remove missing ones, keeping contexts, aliases and settings"`
+	Force  bool     `help:"With --merge, also delete the contexts whose only repository is missing, with their worktrees and branches"`
+
+	NonInteractive bool `help:"Accept the inferred master repository and main branches without reviewing them" name:"non-interactive"`
+}
 
 func (c *ScanCmd) Run(ctx *kong.Context) error {
+	if c.Merge {
+		return c.runMerge()
+	}
+
 	// Check if alfred is already initialized
 	if _, err := os.Stat(filepath.Join(".", ".alfred", "alfred.yaml")); err == nil {
 		fmt.Println("⚠️  Alfred is already initialized in this directory.")
+		fmt.Println("Use 'alfred scan --merge' to only add new and remove missing repositories.")
 		fmt.Print("Do you want to overwrite the existing configuration? (y/N): ")
 
 		var response string
@@ -77,93 +586,180 @@ func (c *ScanCmd) Run(ctx *kong.Context) error {
 		return fmt.Errorf("no Dart/Flutter packages found in current directory")
 	}
 
-	// Convert to TUI format
-	tuiPackages := make([]tui.PackageInfo, len(packages))
-	for i, pkg := range packages {
-		tuiPackages[i] = tui.PackageInfo{
-			Name: pkg.Name,
-			Path: pkg.Path,
-		}
-	}
-
-	// Use TUI to select master repository
-	masterAlias, err := tui.RunPackageSelector(tuiPackages)
-	if err != nil {
-		return fmt.Errorf("failed to select master repository: %w", err)
-	}
+	inference := scan.Infer(".", packages)
+	master := inference.Master
 
-	// Find the selected package to get the correct identifier
-	var masterRepo *DartPackage
-	for _, pkg := range packages {
-		if pkg.Name == masterAlias {
-			masterRepo = &pkg
-			break
+	if !c.NonInteractive {
+		reviewRepos := make([]tui.ReviewRepo, len(inference.Repos))
+		for i, repo := range inference.Repos {
+			reviewRepos[i] = tui.ReviewRepo{
+				Name:       repo.Name,
+				Path:       repo.Path,
+				MainBranch: repo.MainBranch,
+				URL:        repo.URL,
+			}
 		}
-	}
 
-	if masterRepo == nil {
-		return fmt.Errorf("master repository not found in packages")
+		reviewed, reviewedMaster, err := tui.RunScanReview(reviewRepos, inference.Master, inference.MasterReason)
+		switch {
+		case err != nil && (strings.Contains(err.Error(), "TTY") || strings.Contains(err.Error(), "tty")):
+			fmt.Println("No terminal available to review the scan, accepting the inferred values.")
+		case err != nil && strings.Contains(err.Error(), "cancelled"):
+			fmt.Println("Operation " + canceledMessage + ".")
+			return nil
+		case err != nil:
+			return fmt.Errorf("failed to review scanned repositories: %w", err)
+		default:
+			for i := range reviewed {
+				inference.Repos[i].MainBranch = reviewed[i].MainBranch
+			}
+			master = reviewedMaster
+		}
 	}
 
-	// Use alias if set, otherwise use name
-	masterIdentifier := masterRepo.Alias
-	if masterIdentifier == "" {
-		masterIdentifier = masterRepo.Name
+	if master < 0 {
+		master = 0
+		fmt.Printf("⚠️  No master repository could be inferred, using %s. Change 'master' in alfred.yaml if needed\n", inference.Repos[0].Name)
+	} else if master == inference.Master && inference.MasterReason != "" {
+		fmt.Printf("Master repository: %s (%s)\n", inference.Repos[master].Name, inference.MasterReason)
 	}
 
 	// Create alfred configuration
-	mainBranch, err := c.createAlfredConfig(packages, masterIdentifier)
+	mainBranch, err := c.createAlfredConfig(inference.Repos, master)
 	if err != nil {
 		return fmt.Errorf("failed to create alfred configuration: %w", err)
 	}
 
 	fmt.Printf("\n✅ Alfred configured successfully with %d repositories\n", len(packages))
-	fmt.Printf("✅ Master repository: %s\n", masterIdentifier)
+	fmt.Printf("✅ Master repository: %s\n", inference.Repos[master].Name)
 	fmt.Printf("✅ Main branch: %s\n", mainBranch)
 	fmt.Println("✅ You can now use 'alfred switch <context-name>' to create and switch contexts")
 
 	return nil
 }
 
-type DartPackage struct {
-	Name  string
-	Alias string
-	Path  string
+func (c *ScanCmd) scanForDartPackages() ([]scan.Package, error) {
+	opts := scan.Options{
+		Depth:  c.Depth,
+		Ignore: c.Ignore,
+	}
+
+	// Skip worktrees of the contexts already configured
+	if cfg, err := config.LoadConfig(); err == nil {
+		for contextName := range cfg.Contexts {
+			opts.WorktreeSuffixes = append(opts.WorktreeSuffixes, "-"+worktree.SanitizeDirName(contextName))
+		}
+	}
+
+	found, warnings, err := scan.Scan(".", opts)
+	if err != nil {
+		return nil, err
+	}
+	for _, warning := range warnings {
+		fmt.Printf("Warning: %s\n", warning)
+	}
+
+	for _, pkg := range found {
+		if len(pkg.Nested) > 0 {
+			fmt.Printf("📦 %s (%s) also contains %s; only %s is registered\n", pkg.Repo, pkg.Name, strings.Join(pkg.Nested, ", "), pkg.Path)
+		}
+	}
+
+	return found, nil
 }
 
-func (c *ScanCmd) scanForDartPackages() ([]DartPackage, error) {
-	var packages []DartPackage
+// runMerge updates an existing configuration with the packages found on disk:
+// new packages are added and repositories whose pubspec is gone are removed
+func (c *ScanCmd) runMerge() error {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return fmt.Errorf("%w. Run 'alfred scan' without --merge to create a configuration", err)
+	}
 
-	entries, err := os.ReadDir(".")
+	packages, err := c.scanForDartPackages()
 	if err != nil {
-		return nil, fmt.Errorf("failed to read current directory: %w", err)
+		return fmt.Errorf("failed to scan for packages: %w", err)
 	}
 
-	for _, entry := range entries {
-		if !entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
-			continue
+	known := make(map[string]bool)
+	for _, repo := range cfg.Repos {
+		known[filepath.Clean(repo.Path)] = true
+	}
+
+	var newPackages []scan.Package
+	for _, pkg := range packages {
+		if !known[filepath.Clean(pkg.Path)] {
+			newPackages = append(newPackages, pkg)
 		}
+	}
 
-		pubspecPath := filepath.Join(entry.Name(), "pubspec.yaml")
-		if _, err := os.Stat(pubspecPath); os.IsNotExist(err) {
+	var added, removed []string
+	for _, pkg := range scan.Infer(".", newPackages).Repos {
+		repo := config.Repository{Name: pkg.Name, Path: pkg.Path, URL: pkg.URL}
+		if pkg.MainBranch != "" && pkg.MainBranch != cfg.GetMainBranch() {
+			repo.MainBranch = pkg.MainBranch
+		}
+		if _, err := cfg.GetRepoByAlias(pkg.Name); err == nil {
+			// Another repository already uses the package name
+			repo.Alias = scanAlias(pkg.Path)
+		}
+		if err := cfg.AddRepo(repo); err != nil {
+			fmt.Printf("⚠️  Skipping %s: %v\n", pkg.Path, err)
 			continue
 		}
+		added = append(added, fmt.Sprintf("%s (%s)", pkg.Name, pkg.Path))
+	}
 
-		// Read package name from pubspec.yaml
-		packageName, err := pubspec.ExtractPackageNameFromFile(pubspecPath)
-		if err != nil {
-			fmt.Printf("Warning: Could not read package name from %s: %v\n", pubspecPath, err)
+	for _, repo := range append([]config.Repository(nil), cfg.Repos...) {
+		if _, err := os.Stat(filepath.Join(repo.Path, "pubspec.yaml")); err == nil {
 			continue
 		}
 
-		packages = append(packages, DartPackage{
-			Name:  packageName,
-			Alias: "", // Will be set by user if they want a nickname
-			Path:  "./" + entry.Name(),
-		})
+		repoIdentifier := repo.Identifier()
+		wasMaster := cfg.Master == repoIdentifier
+
+		// Contexts left without repositories go through the regular deletion,
+		// which also removes their worktrees and branches
+		if emptied := cfg.ContextsOnlyWith(repoIdentifier); len(emptied) > 0 {
+			if !c.Force {
+				fmt.Printf("⚠️  Keeping %s: it is the only repository of %s. Delete them with 'alfred delete' or run with --force\n",
+					repoIdentifier, strings.Join(emptied, ", "))
+				continue
+			}
+			if err := context.NewManager(cfg).DeleteContexts(emptied, context.DeleteOptions{}); err != nil {
+				return fmt.Errorf("failed to delete contexts of %s: %w", repoIdentifier, err)
+			}
+			for _, contextName := range emptied {
+				fmt.Printf("🗑️  Deleted context '%s', which had no other repositories\n", contextName)
+			}
+		}
+
+		if err := cfg.RemoveRepo(repoIdentifier); err != nil {
+			return err
+		}
+		removed = append(removed, fmt.Sprintf("%s (%s)", repoIdentifier, repo.Path))
+
+		if wasMaster {
+			fmt.Printf("⚠️  Master repository %s no longer exists. Set a new 'master' in alfred.yaml\n", repoIdentifier)
+		}
 	}
 
-	return packages, nil
+	if len(added) == 0 && len(removed) == 0 {
+		fmt.Println("✅ Configuration is up to date")
+		return nil
+	}
+
+	if err := cfg.Save(); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+
+	for _, repo := range added {
+		fmt.Printf("✅ Added %s\n", repo)
+	}
+	for _, repo := range removed {
+		fmt.Printf("🗑️  Removed %s\n", repo)
+	}
+	return nil
 }
 
 // promptForMainBranch prompts the user for the main branch name
@@ -182,31 +778,50 @@ func promptForMainBranch() (string, error) {
 	return branchName, nil
 }
 
-func (c *ScanCmd) createAlfredConfig(packages []DartPackage, masterAlias string) (string, error) {
+func (c *ScanCmd) createAlfredConfig(repos []scan.RepoInfo, master int) (string, error) {
 	// Create .alfred directory
 	alfredDir := filepath.Join(".", ".alfred")
 	if err := os.MkdirAll(alfredDir, 0755); err != nil {
 		return "", fmt.Errorf("failed to create .alfred directory: %w", err)
 	}
 
-	// Get main branch from user
-	mainBranch, err := promptForMainBranch()
-	if err != nil {
-		return "", fmt.Errorf("failed to get main branch: %w", err)
+	// The main branch shared by most repositories becomes the default one
+	var branches []string
+	for _, repo := range repos {
+		branches = append(branches, repo.MainBranch)
+	}
+	mainBranch := scan.MostCommonBranch(branches)
+
+	// Packages sharing a name, e.g. two example apps, are told apart by an
+	// alias, as with scan --merge
+	identifiers := make([]string, len(repos))
+	used := make(map[string]bool)
+	for i, repo := range repos {
+		identifiers[i] = repo.Name
+		if used[repo.Name] {
+			identifiers[i] = scanAlias(repo.Path)
+		}
+		used[identifiers[i]] = true
 	}
 
 	// Create config
 	var configContent strings.Builder
 	configContent.WriteString("repos:\n")
-	for _, pkg := range packages {
-		configContent.WriteString(fmt.Sprintf("  - name: %s\n", pkg.Name))
-		if pkg.Alias != "" {
-			configContent.WriteString(fmt.Sprintf("    alias: %s\n", pkg.Alias))
+	for i, repo := range repos {
+		configContent.WriteString(fmt.Sprintf("  - name: %s\n", repo.Name))
+		if identifiers[i] != repo.Name {
+			configContent.WriteString(fmt.Sprintf("    alias: %s\n", identifiers[i]))
+		}
+		configContent.WriteString(fmt.Sprintf("    path: %s\n", repo.Path))
+		if repo.URL != "" {
+			configContent.WriteString(fmt.Sprintf("    url: %s\n", repo.URL))
+		}
+		if repo.MainBranch != "" && repo.MainBranch != mainBranch {
+			configContent.WriteString(fmt.Sprintf("    main_branch: %s\n", repo.MainBranch))
 		}
-		configContent.WriteString(fmt.Sprintf("    path: %s\n", pkg.Path))
 	}
 
-	configContent.WriteString(fmt.Sprintf("\nmaster: %s\n", masterAlias))
+	configContent.WriteString(fmt.Sprintf("\nmaster: %s\n", identifiers[master]))
 	configContent.WriteString("mode: worktree\n")
 	configContent.WriteString(fmt.Sprintf("main_branch: %s\n", mainBranch))
 	configContent.WriteString("\ncontexts: {}\n")
@@ -227,6 +842,12 @@ func (c *ScanCmd) createAlfredConfig(packages []DartPackage, masterAlias string)
 	return mainBranch, nil
 }
 
+// scanAlias returns the alias of a scanned package whose name is already used
+// by another repository, derived from its path
+func scanAlias(path string) string {
+	return worktree.SanitizeDirName(strings.TrimPrefix(path, "./"))
+}
+
 func (c *ScanCmd) updateGitignore() error {
 	gitignorePath := ".gitignore"
 	alfredIgnoreEntry := ".alfred/"
@@ -280,6 +901,9 @@ func (c *InitCmd) Run(ctx *kong.Context) error {
 	if _, err := os.Stat(configPath); !os.IsNotExist(err) {
 		return fmt.Errorf("alfred is already initialized (.alfred/alfred.yaml exists)")
 	}
+	if _, err := os.Stat(config.ManifestFileName); err == nil {
+		return fmt.Errorf("alfred is already configured by the team manifest (%s exists). Run 'alfred list' to see its contexts", config.ManifestFileName)
+	}
 
 	// Ask user if they want to scan for existing packages
 	fmt.Println("\nChoose initialization method:")
@@ -392,7 +1016,10 @@ func (c *InitCmd) updateGitignore() error {
 	return nil
 }
 
-type StatusCmd struct{}
+type StatusCmd struct {
+	Fetch bool `help:"Fetch remotes before computing ahead/behind counts" short:"f"`
+	All   bool `help:"Show a matrix of every context against every repository" short:"a"`
+}
 
 func (c *StatusCmd) Run(ctx *kong.Context) error {
 	cfg, err := config.LoadConfig()
@@ -401,7 +1028,11 @@ func (c *StatusCmd) Run(ctx *kong.Context) error {
 	}
 
 	manager := context.NewManager(cfg)
-	currentContext, repoStatus, err := manager.GetContextStatus()
+	if c.All {
+		return c.runAll(cfg, manager)
+	}
+
+	currentContext, repoStatus, err := manager.GetContextStatus(status.Options{Fetch: c.Fetch})
 	if err != nil {
 		return fmt.Errorf("failed to get context status: %w", err)
 	}
@@ -424,14 +1055,140 @@ func (c *StatusCmd) Run(ctx *kong.Context) error {
 		return nil
 	}
 
-	fmt.Println("Repository Status:")
-	for repo, status := range repoStatus {
-		fmt.Printf("  %s: %s\n", repo, status)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "REPO\tBRANCH\tUPSTREAM\t↑/↓\tSTAGED\tUNSTAGED\tUNTRACKED\tSTASHES\tDEPS\tLAST COMMIT")
+
+	var problems []string
+	for _, st := range repoStatus {
+		name := st.Name
+		if st.IsMaster {
+			name += " (master)"
+		}
+
+		if !st.Exists {
+			_, _ = fmt.Fprintf(w, "%s\t-\t-\t-\t-\t-\t-\t-\t-\t-\n", name)
+			problems = append(problems, fmt.Sprintf("%s: %s", st.Name, st.Error))
+			continue
+		}
+
+		upstream, aheadBehind := "-", "-"
+		if st.Upstream != "" {
+			upstream = st.Upstream
+			aheadBehind = fmt.Sprintf("%d/%d", st.Ahead, st.Behind)
+		}
+
+		lastCommit := "-"
+		if st.LastCommit != nil {
+			subject := st.LastCommit.Subject
+			if len(subject) > 40 {
+				subject = subject[:37] + "..."
+			}
+			lastCommit = fmt.Sprintf("%s %s (%s)", st.LastCommit.Hash, subject, status.FormatAge(st.LastCommit.Date))
+		}
+
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\t%d\t%d\t%d\t%s\t%s\n",
+			name, st.Branch, upstream, aheadBehind,
+			st.Changes.Staged, st.Changes.Unstaged, st.Changes.Untracked,
+			st.Stashes, st.Deps, lastCommit)
+
+		if st.Error != "" {
+			problems = append(problems, fmt.Sprintf("%s: %s", st.Name, st.Error))
+		}
+	}
+	_ = w.Flush()
+
+	if len(problems) > 0 {
+		fmt.Println()
+		for _, problem := range problems {
+			fmt.Printf("⚠️  %s\n", problem)
+		}
+	}
+
+	if !c.Fetch {
+		fmt.Println()
+		fmt.Println("Ahead/behind counts use the last fetch. Run 'alfred status --fetch' to update them.")
+	}
+
+	return nil
+}
+
+// runAll prints the status of every context branch in every repository
+func (c *StatusCmd) runAll(cfg *config.Config, manager *context.Manager) error {
+	if c.Fetch {
+		for _, repo := range cfg.Repos {
+			if err := git.NewGitRepo(repo.Path).Fetch(repo.GetRemote()); err != nil {
+				fmt.Printf("⚠️  %s: %v\n", repo.Name, err)
+			}
+		}
+	}
+
+	rows, err := manager.GetAllContextsStatus()
+	if err != nil {
+		return fmt.Errorf("failed to get contexts status: %w", err)
+	}
+
+	if len(rows) == 0 {
+		fmt.Println("No contexts defined in alfred.yaml")
+		return nil
+	}
+
+	repoAliases := cfg.GetRepoAliases()
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	_, _ = fmt.Fprintf(w, "CONTEXT\t%s\n", strings.Join(repoAliases, "\t"))
+	for _, row := range rows {
+		name := row.Name
+		if row.Current {
+			name = "● " + name
+		}
+
+		cells := make([]string, len(repoAliases))
+		for i, alias := range repoAliases {
+			cells[i] = formatBranchCell(row.Repos[alias])
+		}
+		_, _ = fmt.Fprintf(w, "%s\t%s\n", name, strings.Join(cells, "\t"))
 	}
+	_ = w.Flush()
 
+	fmt.Println()
+	fmt.Println("·  not in context   none  branch missing   wt  worktree exists   no-wt  worktree missing")
+	fmt.Println("dirty  uncommitted changes   ↑N  unpushed commits   merged  merged into main   age of last commit")
 	return nil
 }
 
+// formatBranchCell renders a compact summary of a context branch in one repository
+func formatBranchCell(st *status.BranchStatus) string {
+	if st == nil || !st.InContext {
+		return "·"
+	}
+	if !st.BranchExists {
+		return "none"
+	}
+
+	var parts []string
+	if st.UsesWorktree {
+		if st.WorktreeExists {
+			parts = append(parts, "wt")
+		} else {
+			parts = append(parts, "no-wt")
+		}
+	}
+	if st.Dirty {
+		parts = append(parts, "dirty")
+	}
+	if st.Unpushed > 0 {
+		parts = append(parts, fmt.Sprintf("↑%d", st.Unpushed))
+	}
+	if st.Merged {
+		parts = append(parts, "merged")
+	}
+	if !st.LastCommit.IsZero() {
+		parts = append(parts, status.FormatAge(st.LastCommit))
+	}
+
+	return strings.Join(parts, " ")
+}
+
 type ListCmd struct{}
 
 func (c *ListCmd) Run(ctx *kong.Context) error {
@@ -464,17 +1221,118 @@ func (c *ListCmd) Run(ctx *kong.Context) error {
 				fmt.Printf("  %s - main/master branches for all repos\n", contextName)
 			}
 		case currentContext:
-			fmt.Printf("● %s (current)\n", contextName)
+			fmt.Printf("● %s (current)%s%s\n", contextName, sharedSuffix(cfg, contextName), summarySuffix(cfg, contextName))
 		default:
-			fmt.Printf("  %s\n", contextName)
+			fmt.Printf("  %s%s%s\n", contextName, sharedSuffix(cfg, contextName), summarySuffix(cfg, contextName))
 		}
 	}
 
 	return nil
 }
 
+// contextSummary describes a context in one line from its metadata: its
+// description, ticket, owner, base, mode override and when it was last used
+func contextSummary(cfg *config.Config, contextName string) string {
+	info := cfg.GetContextInfo(contextName)
+
+	var parts []string
+	for _, part := range []string{info.Description, info.Ticket, info.Owner} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	if info.Base != "" {
+		parts = append(parts, "from "+info.Base)
+	}
+	if info.Mode != "" && info.Mode != cfg.Mode {
+		parts = append(parts, info.Mode+" mode")
+	}
+	switch {
+	case !info.LastSwitched.IsZero():
+		parts = append(parts, "switched "+formatAgo(info.LastSwitched))
+	case !info.Created.IsZero():
+		parts = append(parts, "created "+formatAgo(info.Created))
+	}
+	return strings.Join(parts, " • ")
+}
+
+func summarySuffix(cfg *config.Config, contextName string) string {
+	if summary := contextSummary(cfg, contextName); summary != "" {
+		return " - " + summary
+	}
+	return ""
+}
+
+func formatAgo(t time.Time) string {
+	if age := status.FormatAge(t); age != "now" {
+		return age + " ago"
+	}
+	return "just now"
+}
+
+// sharedSuffix marks contexts that come from the team manifest
+func sharedSuffix(cfg *config.Config, contextName string) string {
+	if cfg.IsSharedContext(contextName) {
+		return " (shared)"
+	}
+	return ""
+}
+
+type HistoryCmd struct {
+	Limit int `help:"Number of switches to show" short:"n" default:"20"`
+}
+
+func (c *HistoryCmd) Run(ctx *kong.Context) error {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	manager := context.NewManager(cfg)
+	entries, err := manager.GetHistory(c.Limit)
+	if err != nil {
+		return err
+	}
+
+	if len(entries) == 0 {
+		fmt.Println("No context switches recorded yet")
+		return nil
+	}
+
+	currentContext, _ := manager.GetCurrentContext()
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "WHEN\tCONTEXT\tFROM\tDURATION")
+	for i, entry := range entries {
+		duration := formatDuration(entry.Duration)
+		if i == 0 && entry.To == currentContext {
+			duration += " (current)"
+		}
+		from := entry.From
+		if from == "" {
+			from = "-"
+		}
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", entry.Time.Local().Format("2006-01-02 15:04"), entry.To, from, duration)
+	}
+	return w.Flush()
+}
+
+// formatDuration shows the time spent in a context with its two largest units
+func formatDuration(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh%02dm", int(d.Hours()), int(d.Minutes())%60)
+	default:
+		return fmt.Sprintf("%dd%dh", int(d.Hours())/24, int(d.Hours())%24)
+	}
+}
+
 type SwitchCmd struct {
-	Context string `arg:"" help:"Context name to switch to" optional:"true"`
+	Context string `arg:"" help:"Context name to switch to, or - for the previous context" optional:"true"`
 }
 
 func (c *SwitchCmd) Run(ctx *kong.Context) error {
@@ -484,7 +1342,15 @@ func (c *SwitchCmd) Run(ctx *kong.Context) error {
 	}
 
 	manager := context.NewManager(cfg)
-	contexts := manager.ListContexts()
+	contexts := manager.ListContextsByRecentUse()
+
+	if c.Context == "-" {
+		previous, err := manager.PreviousContext()
+		if err != nil {
+			return err
+		}
+		c.Context = previous
+	}
 
 	var targetContext string
 
@@ -532,7 +1398,11 @@ func (c *SwitchCmd) Run(ctx *kong.Context) error {
 
 		// Try to use TUI, but fallback to showing available contexts if no TTY
 		currentContext, _ := manager.GetCurrentContext()
-		selectedContext, err := tui.RunContextSelector(contexts, currentContext)
+		details := make(map[string]string, len(contexts))
+		for _, ctx := range contexts {
+			details[ctx] = contextSummary(cfg, ctx)
+		}
+		selectedContext, err := tui.RunContextSelector(contexts, details, currentContext)
 		if err != nil {
 			// If TTY error, show available contexts and prompt user to specify one
 			if strings.Contains(err.Error(), "TTY") || strings.Contains(err.Error(), "tty") {
@@ -575,8 +1445,7 @@ func (c *SwitchCmd) createNewContext(cfg *config.Config, contextName string) err
 		return fmt.Errorf("no repositories configured in alfred.yaml")
 	}
 
-	repoAliases := cfg.GetRepoAliases()
-	repoPaths := cfg.GetRepoPaths()
+	repoAliases, repoPaths := repoChoices(cfg)
 
 	fmt.Printf("\nSelect repositories for context '%s':\n", contextName)
 	selectedRepos, err := tui.RunRepoSelector(repoAliases, repoPaths)
@@ -643,19 +1512,67 @@ func (c *SwitchCmd) interactiveRepoSelection(repoAliases []string) ([]string, er
 			continue
 		}
 
-		selectedRepos = append(selectedRepos, repoAliases[index-1])
+		selectedRepos = append(selectedRepos, repoAliases[index-1])
+	}
+
+	if len(selectedRepos) == 0 {
+		return nil, fmt.Errorf("no valid repositories selected")
+	}
+
+	return selectedRepos, nil
+}
+
+type CreateCmd struct {
+	Name  string   `arg:"" help:"Context name" optional:"true"`
+	Repos []string `help:"Repository aliases or @groups to include, comma-separated (e.g. @payments,app)"`
+	ContextInfoFlags
+}
+
+// ContextInfoFlags sets the metadata of a context
+type ContextInfoFlags struct {
+	Description *string `help:"What the context is for"`
+	Ticket      *string `help:"Ticket or issue key, available as {ticket} in templates"`
+	Owner       *string `help:"Who owns the context"`
+	Base        *string `help:"Ref the context branches from"`
+	Mode        *string `help:"Check the context out in worktrees or in the repositories themselves, overriding the global mode (worktree or branch)"`
+}
+
+func (f ContextInfoFlags) isSet() bool {
+	return f.Description != nil || f.Ticket != nil || f.Owner != nil || f.Base != nil || f.Mode != nil
+}
+
+func (f ContextInfoFlags) validate() error {
+	if f.Mode != nil && *f.Mode != "" && *f.Mode != config.ModeBranch && *f.Mode != config.ModeWorktree {
+		return fmt.Errorf("invalid mode '%s'. Must be 'branch' or 'worktree'", *f.Mode)
 	}
+	return nil
+}
 
-	if len(selectedRepos) == 0 {
-		return nil, fmt.Errorf("no valid repositories selected")
+// apply overlays the flags given on info
+func (f ContextInfoFlags) apply(info config.ContextInfo) config.ContextInfo {
+	if f.Description != nil {
+		info.Description = *f.Description
 	}
-
-	return selectedRepos, nil
+	if f.Ticket != nil {
+		info.Ticket = *f.Ticket
+	}
+	if f.Owner != nil {
+		info.Owner = *f.Owner
+	}
+	if f.Base != nil {
+		info.Base = *f.Base
+	}
+	if f.Mode != nil {
+		info.Mode = *f.Mode
+	}
+	return info
 }
 
-type CreateCmd struct{}
-
 func (c *CreateCmd) Run(ctx *kong.Context) error {
+	if err := c.validate(); err != nil {
+		return err
+	}
+
 	cfg, err := config.LoadConfig()
 	if err != nil {
 		return err
@@ -665,10 +1582,17 @@ func (c *CreateCmd) Run(ctx *kong.Context) error {
 		return fmt.Errorf("no repositories configured in alfred.yaml")
 	}
 
-	repoAliases := cfg.GetRepoAliases()
-	repoPaths := cfg.GetRepoPaths()
+	repoAliases, repoPaths := repoChoices(cfg)
 
-	contextName, selectedRepos, err := tui.RunContextCreator(repoAliases, repoPaths)
+	contextName, selectedRepos := c.Name, c.Repos
+	switch {
+	case contextName == "" && len(selectedRepos) > 0:
+		return fmt.Errorf("a context name is required with --repos")
+	case contextName == "":
+		contextName, selectedRepos, err = tui.RunContextCreator(repoAliases, repoPaths)
+	case len(selectedRepos) == 0:
+		selectedRepos, err = tui.RunRepoSelectorWithTitle(fmt.Sprintf("Select repositories for '%s'", contextName), repoAliases, repoPaths)
+	}
 	if err != nil {
 		return err
 	}
@@ -687,6 +1611,7 @@ func (c *CreateCmd) Run(ctx *kong.Context) error {
 	if err := cfg.AddContext(contextName, selectedRepos); err != nil {
 		return fmt.Errorf("failed to add context: %w", err)
 	}
+	cfg.SetContextInfo(contextName, c.apply(cfg.GetContextInfo(contextName)))
 
 	// Save config
 	if err := cfg.Save(); err != nil {
@@ -695,12 +1620,29 @@ func (c *CreateCmd) Run(ctx *kong.Context) error {
 
 	fmt.Printf("✅ Created context '%s' with repositories: %s\n",
 		contextName, strings.Join(selectedRepos, ", "))
+	if slices.ContainsFunc(selectedRepos, config.IsGroupRef) {
+		aliases := cfg.GetContextAliases(contextName)
+		fmt.Printf("   Groups expand to: %s\n", strings.Join(aliases, ", "))
+	}
 
 	return nil
 }
 
+// repoChoices lists the groups, as @name with their members, followed by the
+// repositories with their paths, for the repository selectors
+func repoChoices(cfg *config.Config) ([]string, []string) {
+	var refs, details []string
+	for _, name := range cfg.GetGroupNames() {
+		refs = append(refs, config.GroupPrefix+name)
+		details = append(details, strings.Join(cfg.Groups[name], ", "))
+	}
+	return append(refs, cfg.GetRepoAliases()...), append(details, cfg.GetRepoPaths()...)
+}
+
 type DeleteCmd struct {
 	Contexts []string `arg:"" help:"Context names to delete" optional:"true"`
+	Force    bool     `help:"Delete even if uncommitted changes, unpushed commits or stashes would be lost" short:"f"`
+	Backup   bool     `help:"Keep branch tips and uncommitted changes under refs/alfred/trash/"`
 }
 
 func (c *DeleteCmd) Run(ctx *kong.Context) error {
@@ -737,11 +1679,43 @@ func (c *DeleteCmd) Run(ctx *kong.Context) error {
 				return fmt.Errorf("context '%s' not found", contextName)
 			}
 		}
-		targetContexts = c.Contexts
+
+		// Refuse to silently lose work unless forced or confirmed per repository
+		for _, contextName := range c.Contexts {
+			risk, err := manager.CheckDeleteSafety(contextName)
+			if err != nil {
+				return err
+			}
+			if !c.Force && risk.HasRisk() && !c.confirmRiskyDelete(risk) {
+				fmt.Printf("Keeping context '%s'\n", contextName)
+				continue
+			}
+			targetContexts = append(targetContexts, contextName)
+		}
+
+		if len(targetContexts) == 0 {
+			fmt.Println("No contexts deleted.")
+			return nil
+		}
 	} else {
+		// Collect what each context would lose so the TUI can show it before confirming
+		risks := make(map[string][]string)
+		for _, contextName := range allContexts {
+			if contextName == "main" || contextName == "master" {
+				continue
+			}
+			risk, err := manager.CheckDeleteSafety(contextName)
+			if err != nil {
+				return err
+			}
+			if risk.HasRisk() {
+				risks[contextName] = risk.Lines()
+			}
+		}
+
 		// Use TUI to select contexts
 		currentContext, _ := manager.GetCurrentContext()
-		selectedContexts, err := tui.RunContextDeleter(allContexts, currentContext)
+		selectedContexts, err := tui.RunContextDeleter(allContexts, currentContext, risks)
 		if err != nil {
 			// If TTY error, show available contexts and prompt user to specify them
 			if strings.Contains(err.Error(), "TTY") || strings.Contains(err.Error(), "tty") {
@@ -768,14 +1742,40 @@ func (c *DeleteCmd) Run(ctx *kong.Context) error {
 	}
 
 	// Perform deletion
-	if err := manager.DeleteContexts(targetContexts); err != nil {
+	if err := manager.DeleteContexts(targetContexts, context.DeleteOptions{Backup: c.Backup}); err != nil {
 		return fmt.Errorf("failed to delete contexts: %w", err)
 	}
 
 	fmt.Printf("✅ Successfully deleted contexts: %s\n", strings.Join(targetContexts, ", "))
+	if c.Backup {
+		fmt.Printf("   Backups are kept under %s (list them with 'git for-each-ref %s')\n", context.TrashRefPrefix, context.TrashRefPrefix)
+	}
 	return nil
 }
 
+// confirmRiskyDelete shows what deleting a context would lose and asks for
+// confirmation for every repository at risk
+func (c *DeleteCmd) confirmRiskyDelete(risk *context.DeleteRisk) bool {
+	fmt.Printf("⚠️  Deleting context '%s' would lose work:\n", risk.Context)
+	for _, line := range risk.Lines() {
+		fmt.Printf("     %s\n", line)
+	}
+
+	for _, repoRisk := range risk.Repos {
+		if !repoRisk.HasRisk() {
+			continue
+		}
+
+		fmt.Printf("   Discard %s in %s? (y/N): ", strings.Join(repoRisk.Describe(), ", "), repoRisk.Name)
+		var response string
+		_, _ = fmt.Scanln(&response)
+		if strings.ToLower(response) != "y" && strings.ToLower(response) != "yes" {
+			return false
+		}
+	}
+	return true
+}
+
 type PrepareCmd struct {
 	Repository string `arg:"" help:"Repository to prepare (alias or name). If not specified, prepares current master repository" optional:"true"`
 }
@@ -810,10 +1810,7 @@ func (c *PrepareCmd) Run(ctx *kong.Context) error {
 		return fmt.Errorf("failed to load pubspec.yaml from %s: %w", targetRepo.Path, err)
 	}
 
-	repoIdentifier := targetRepo.Alias
-	if repoIdentifier == "" {
-		repoIdentifier = targetRepo.Name
-	}
+	repoIdentifier := targetRepo.Identifier()
 
 	fmt.Printf("Preparing %s for production by reverting to git dependencies...\n", repoIdentifier)
 
@@ -949,28 +1946,18 @@ func (c *CommitCmd) Run(ctx *kong.Context) error {
 
 	// Create git repo instances for each repository
 	gitRepos := make(map[string]*git.GitRepo)
+	worktreeManager := worktree.NewManager(cfg)
 	for _, repo := range repos {
-		repoIdentifier := repo.Alias
-		if repoIdentifier == "" {
-			repoIdentifier = repo.Name
-		}
+		repoIdentifier := repo.Identifier()
 
 		// Determine the correct path based on context and mode
-		var repoPath string
-		if cfg.IsBranchMode() || repo.Alias == cfg.Master {
-			// In branch mode or for master repo, use original path
-			repoPath = repo.Path
-		} else {
-			// In worktree mode for non-master repos, use worktree path
-			worktreeManager := worktree.NewManager(cfg)
-			repoPath = worktreeManager.GetWorktreePath(repo, currentContext)
-		}
+		repoPath := worktreeManager.GetRepoPath(repo, currentContext)
 
 		gitRepos[repoIdentifier] = git.NewGitRepo(repoPath)
 	}
 
-	// Run the interactive commit interface
-	if err := tui.RunCommitInterface(gitRepos); err != nil {
+	// Run the interactive commit interface, suggesting the message of commit_template
+	if err := tui.RunCommitInterface(gitRepos, cfg.GetCommitMessage(currentContext)); err != nil {
 		return fmt.Errorf("commit interface error: %w", err)
 	}
 
@@ -1012,23 +1999,13 @@ func (c *PushCmd) Run(ctx *kong.Context) error {
 
 	var errors []string
 	var successes []string
+	worktreeManager := worktree.NewManager(cfg)
 
 	for _, repo := range repos {
-		repoIdentifier := repo.Alias
-		if repoIdentifier == "" {
-			repoIdentifier = repo.Name
-		}
+		repoIdentifier := repo.Identifier()
 
 		// Determine the correct path based on context and mode
-		var repoPath string
-		if cfg.IsBranchMode() || repo.Alias == cfg.Master {
-			// In branch mode or for master repo, use original path
-			repoPath = repo.Path
-		} else {
-			// In worktree mode for non-master repos, use worktree path
-			worktreeManager := worktree.NewManager(cfg)
-			repoPath = worktreeManager.GetWorktreePath(repo, currentContext)
-		}
+		repoPath := worktreeManager.GetRepoPath(repo, currentContext)
 
 		fmt.Printf("📤 Pushing %s...", repoIdentifier)
 
@@ -1036,7 +2013,14 @@ func (c *PushCmd) Run(ctx *kong.Context) error {
 		gitRepo := git.NewGitRepo(repoPath)
 
 		var err error
-		if c.SetUpstream {
+		if repo.IsTriangular() {
+			// Fork workflow: push to the push remote and keep tracking the main
+			// branch of the fetch remote so pulls rebase onto it
+			err = gitRepo.PushToRemote(repo.GetPushRemote())
+			if err == nil {
+				err = gitRepo.SetTriangularTracking(repo.GetRemote(), cfg.GetRepoMainBranch(repo), repo.GetPushRemote())
+			}
+		} else if c.SetUpstream {
 			// Force set upstream even if already configured
 			currentBranch, branchErr := gitRepo.GetCurrentBranch()
 			if branchErr != nil {
@@ -1045,7 +2029,7 @@ func (c *PushCmd) Run(ctx *kong.Context) error {
 				continue
 			}
 
-			if setErr := gitRepo.SetUpstream("origin", currentBranch); setErr != nil {
+			if setErr := gitRepo.SetUpstream(repo.GetPushRemote(), currentBranch); setErr != nil {
 				fmt.Printf(" ❌\n")
 				errors = append(errors, fmt.Sprintf("%s: failed to set upstream: %v", repoIdentifier, setErr))
 				continue
@@ -1060,7 +2044,7 @@ func (c *PushCmd) Run(ctx *kong.Context) error {
 			}
 		} else {
 			// Use the automatic upstream push method
-			err = gitRepo.PushWithUpstream("origin")
+			err = gitRepo.PushWithUpstream(repo.GetPushRemote())
 		}
 
 		if err != nil {
@@ -1125,29 +2109,26 @@ func (c *PullCmd) Run(ctx *kong.Context) error {
 
 	var errors []string
 	var successes []string
+	worktreeManager := worktree.NewManager(cfg)
 
 	for _, repo := range repos {
-		repoIdentifier := repo.Alias
-		if repoIdentifier == "" {
-			repoIdentifier = repo.Name
-		}
+		repoIdentifier := repo.Identifier()
 
 		// Determine the correct path based on context and mode
-		var repoPath string
-		if cfg.IsBranchMode() || repo.Alias == cfg.Master {
-			// In branch mode or for master repo, use original path
-			repoPath = repo.Path
-		} else {
-			// In worktree mode for non-master repos, use worktree path
-			worktreeManager := worktree.NewManager(cfg)
-			repoPath = worktreeManager.GetWorktreePath(repo, currentContext)
-		}
+		repoPath := worktreeManager.GetRepoPath(repo, currentContext)
 
 		fmt.Printf("📥 Pulling %s...", repoIdentifier)
 
 		// Create git repo instance and use the new pull method with automatic upstream
 		gitRepo := git.NewGitRepo(repoPath)
-		err := gitRepo.Pull(c.Rebase)
+
+		var err error
+		if repo.IsTriangular() {
+			// Fork workflow: update from the main branch of the fetch remote
+			err = gitRepo.PullFrom(repo.GetRemote(), cfg.GetRepoMainBranch(repo), c.Rebase)
+		} else {
+			err = gitRepo.Pull(repo.GetRemote(), c.Rebase)
+		}
 
 		if err != nil {
 			fmt.Printf(" ❌\n")
@@ -1206,26 +2187,17 @@ func (c *DiagnoseCmd) Run(ctx *kong.Context) error {
 
 	fmt.Printf("🔍 Diagnosing context '%s'...\n", currentContext)
 	fmt.Println()
+	worktreeManager := worktree.NewManager(cfg)
 
 	for _, repo := range repos {
-		repoIdentifier := repo.Alias
-		if repoIdentifier == "" {
-			repoIdentifier = repo.Name
-		}
+		repoIdentifier := repo.Identifier()
 
 		// Determine the correct path based on context and mode
-		var repoPath string
-		if cfg.IsBranchMode() || repo.Alias == cfg.Master {
-			// In branch mode or for master repo, use original path
-			repoPath = repo.Path
-		} else {
-			// In worktree mode for non-master repos, use worktree path
-			worktreeManager := worktree.NewManager(cfg)
-			repoPath = worktreeManager.GetWorktreePath(repo, currentContext)
-		}
+		repoPath := worktreeManager.GetRepoPath(repo, currentContext)
 
 		fmt.Printf("📁 Repository: %s\n", repoIdentifier)
 		fmt.Printf("   Path: %s\n", repoPath)
+		fmt.Printf("   Main branch: %s\n", cfg.GetRepoMainBranch(repo))
 
 		gitRepo := git.NewGitRepo(repoPath)
 
@@ -1244,6 +2216,9 @@ func (c *DiagnoseCmd) Run(ctx *kong.Context) error {
 			fmt.Printf("   🌿 Current branch: %s\n", currentBranch)
 		}
 
+		// Explain the remote topology
+		c.printRemoteTopology(gitRepo, repo, cfg.GetRepoMainBranch(repo), currentBranch)
+
 		// Check upstream configuration
 		hasUpstream, err := gitRepo.HasUpstream()
 		if err != nil {
@@ -1255,14 +2230,14 @@ func (c *DiagnoseCmd) Run(ctx *kong.Context) error {
 
 			// Check if remote branch exists
 			if currentBranch != "" {
-				checkCmd := exec.Command("git", "-C", repoPath, "ls-remote", "--heads", "origin", currentBranch)
-				checkOutput, checkErr := checkCmd.Output()
+				remote := repo.GetPushRemote()
+				exists, checkErr := gitRepo.RemoteBranchExists(remote, currentBranch)
 				if checkErr != nil {
 					fmt.Printf("   ❌ Failed to check remote branch: %v\n", checkErr)
-				} else if len(strings.TrimSpace(string(checkOutput))) == 0 {
-					fmt.Printf("   ⚠️  Remote branch 'origin/%s' does not exist\n", currentBranch)
+				} else if !exists {
+					fmt.Printf("   ⚠️  Remote branch '%s/%s' does not exist\n", remote, currentBranch)
 				} else {
-					fmt.Printf("   ✅ Remote branch 'origin/%s' exists\n", currentBranch)
+					fmt.Printf("   ✅ Remote branch '%s/%s' exists\n", remote, currentBranch)
 				}
 			}
 		}
@@ -1283,6 +2258,349 @@ func (c *DiagnoseCmd) Run(ctx *kong.Context) error {
 	return nil
 }
 
+type SyncCmd struct {
+	Merge    bool `help:"Merge the main branch into each context branch" xor:"strategy"`
+	Rebase   bool `help:"Rebase each context branch onto the main branch (default)" xor:"strategy"`
+	Continue bool `help:"Continue a sync after resolving conflicts" xor:"action,strategy"`
+	Abort    bool `help:"Abort a sync and restore every repository" xor:"action,strategy"`
+}
+
+func (c *SyncCmd) Run(ctx *kong.Context) error {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	manager := context.NewManager(cfg)
+
+	var state *context.SyncState
+	switch {
+	case c.Abort:
+		state, err = manager.AbortSync()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("✅ Sync of context '%s' aborted, repositories restored\n", state.Context)
+		return nil
+
+	case c.Continue:
+		state, err = manager.ContinueSync()
+
+	default:
+		strategy := context.SyncRebase
+		if c.Merge {
+			strategy = context.SyncMerge
+		}
+		state, err = manager.SyncContext(strategy)
+	}
+
+	if state != nil {
+		fmt.Printf("Syncing context '%s' (%s)...\n", state.Context, state.Strategy)
+		fmt.Println()
+		for _, repoState := range state.Repos {
+			icon := "⏳"
+			switch repoState.Status {
+			case context.SyncStatusDone:
+				icon = "✅"
+			case context.SyncStatusConflict:
+				icon = "⚠️ "
+			}
+			fmt.Printf("  %s %s onto %s\n", icon, repoState.Name, repoState.Onto)
+		}
+		fmt.Println()
+	}
+
+	var conflict *context.SyncConflictError
+	if errors.As(err, &conflict) {
+		fmt.Printf("⚠️  Sync is waiting on %s\n", conflict.Repo)
+		fmt.Printf("   Resolve the conflicts in %s and stage them, then run 'alfred sync --continue'\n", conflict.Path)
+		fmt.Println("   Run 'alfred sync --abort' to restore every repository")
+		return fmt.Errorf("sync stopped due to conflicts in %s", conflict.Repo)
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("✅ All repositories are up to date with their main branch")
+	return nil
+}
+
+type FetchContextCmd struct {
+	Name string `arg:"" help:"Context name"`
+}
+
+func (c *FetchContextCmd) Run(ctx *kong.Context) error {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	manager := context.NewManager(cfg)
+	repos, err := manager.FetchContext(c.Name)
+	if err != nil {
+		return fmt.Errorf("failed to fetch context: %w", err)
+	}
+
+	fmt.Printf("✅ Context '%s' checked out with repositories: %s\n", c.Name, strings.Join(repos, ", "))
+	return nil
+}
+
+type BootstrapCmd struct {
+	Jobs int `help:"Number of repositories cloned in parallel" short:"j" default:"4"`
+}
+
+func (c *BootstrapCmd) Run(ctx *kong.Context) error {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Bootstrapping %d repositories...\n", len(cfg.Repos))
+	fmt.Println()
+
+	manager := context.NewManager(cfg)
+	results := manager.Bootstrap(c.Jobs, func(result context.BootstrapResult) {
+		switch result.Status {
+		case context.BootstrapCloned:
+			fmt.Printf("  ✅ %s cloned into %s (%s)\n", result.Name, result.Path, result.Branch)
+		case context.BootstrapPresent:
+			fmt.Printf("  ✅ %s already present at %s\n", result.Name, result.Path)
+		case context.BootstrapSkipped:
+			fmt.Printf("  ⚠️  %s skipped: %v\n", result.Name, result.Err)
+		default:
+			fmt.Printf("  ❌ %s: %v\n", result.Name, result.Err)
+		}
+	})
+
+	var cloned, failed, skipped int
+	for _, result := range results {
+		switch result.Status {
+		case context.BootstrapCloned:
+			cloned++
+		case context.BootstrapFailed:
+			failed++
+		case context.BootstrapSkipped:
+			skipped++
+		}
+	}
+
+	fmt.Println()
+	fmt.Printf("Cloned %d, already present %d, skipped %d, failed %d\n", cloned, len(results)-cloned-failed-skipped, skipped, failed)
+	if skipped > 0 {
+		fmt.Println("Add a 'url' to the skipped repositories in alfred.yaml to clone them.")
+	}
+	if failed > 0 {
+		return fmt.Errorf("failed to bootstrap %d repositories", failed)
+	}
+	return nil
+}
+
+type PruneCmd struct {
+	IdleDays int  `help:"Also prune contexts without commits for this many days" name:"idle-days" default:"0"`
+	Yes      bool `help:"Delete all prunable contexts without asking" short:"y"`
+	Remote   bool `help:"Also delete the context branches on the push remote"`
+	Backup   bool `help:"Keep branch tips under refs/alfred/trash/"`
+	DryRun   bool `help:"Only show which contexts would be pruned" name:"dry-run"`
+}
+
+func (c *PruneCmd) Run(ctx *kong.Context) error {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	manager := context.NewManager(cfg)
+	candidates, err := manager.FindPruneCandidates(c.IdleDays)
+	if err != nil {
+		return err
+	}
+
+	if len(candidates) == 0 {
+		fmt.Println("✅ No contexts to prune")
+		return nil
+	}
+
+	var targetContexts []string
+	for _, candidate := range candidates {
+		if !candidate.CanPrune() {
+			fmt.Printf("⚠️  Keeping '%s' (%s):\n", candidate.Name, strings.Join(candidate.Reasons, ", "))
+			for _, blocker := range candidate.Blockers {
+				fmt.Printf("     %s\n", blocker)
+			}
+			continue
+		}
+
+		fmt.Printf("🗑️  '%s': %s\n", candidate.Name, strings.Join(candidate.Reasons, ", "))
+		if c.DryRun {
+			continue
+		}
+
+		if !c.Yes {
+			fmt.Printf("   Delete context '%s'? (y/N): ", candidate.Name)
+			var response string
+			_, _ = fmt.Scanln(&response)
+			if strings.ToLower(response) != "y" && strings.ToLower(response) != "yes" {
+				continue
+			}
+		}
+
+		targetContexts = append(targetContexts, candidate.Name)
+	}
+
+	if c.DryRun || len(targetContexts) == 0 {
+		fmt.Println("\nNo contexts deleted.")
+		return nil
+	}
+
+	// Remote branches are removed first since deleting the context drops its repositories from the config
+	if c.Remote {
+		for _, contextName := range targetContexts {
+			if err := manager.DeleteRemoteBranches(contextName); err != nil {
+				fmt.Printf("⚠️  %s: %v\n", contextName, err)
+			}
+		}
+	}
+
+	if err := manager.DeleteContexts(targetContexts, context.DeleteOptions{Backup: c.Backup}); err != nil {
+		return fmt.Errorf("failed to delete contexts: %w", err)
+	}
+
+	fmt.Printf("\n✅ Pruned contexts: %s\n", strings.Join(targetContexts, ", "))
+	return nil
+}
+
+type WorktreeCmd struct {
+	Migrate WorktreeMigrateCmd `cmd:"" help:"Move existing worktrees to the configured worktree_dir layout"`
+}
+
+type WorktreeMigrateCmd struct {
+	From   string `help:"Previous worktree_dir layout (defaults to the legacy <repo-path>-<context> layout)"`
+	DryRun bool   `help:"Only show which worktrees would be moved"`
+}
+
+func (c *WorktreeMigrateCmd) Run(ctx *kong.Context) error {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	manager := context.NewManager(cfg)
+	if !slices.ContainsFunc(manager.ListContexts(), func(name string) bool {
+		return name != "main" && !cfg.IsContextBranchMode(name)
+	}) {
+		fmt.Println("Every context is in branch mode, there are no worktrees to migrate.")
+		return nil
+	}
+
+	if c.From == cfg.WorktreeDir {
+		return fmt.Errorf("--from matches the configured worktree_dir, nothing to migrate")
+	}
+
+	var contexts []string
+	for _, contextName := range manager.ListContexts() {
+		if contextName != "main" && contextName != "master" && !cfg.IsContextBranchMode(contextName) {
+			contexts = append(contexts, contextName)
+		}
+	}
+
+	// Nothing is moved unless every worktree gets a directory of its own
+	worktreeManager := worktree.NewManager(cfg)
+	if err := worktreeManager.CheckWorktreePaths(contexts); err != nil {
+		return err
+	}
+
+	var moved []string
+	var errors []string
+
+	for _, contextName := range contexts {
+		repos, err := cfg.GetNonMasterReposForContext(contextName)
+		if err != nil {
+			errors = append(errors, fmt.Sprintf("%s: %v", contextName, err))
+			continue
+		}
+
+		for _, repo := range repos {
+			oldPath, newPath, migrated, err := worktreeManager.MigrateWorktreeForContext(repo, contextName, c.From, c.DryRun)
+			if err != nil {
+				errors = append(errors, fmt.Sprintf("%s/%s: %v", contextName, repo.Name, err))
+				continue
+			}
+			if migrated {
+				moved = append(moved, contextName)
+				fmt.Printf("📦 %s → %s\n", oldPath, newPath)
+			}
+		}
+	}
+
+	if len(moved) == 0 && len(errors) == 0 {
+		fmt.Println("No worktrees to migrate.")
+		return nil
+	}
+
+	if !c.DryRun && len(moved) > 0 {
+		// Path dependencies of the active context point at the old locations
+		currentContext, _ := manager.GetCurrentContext()
+		for _, contextName := range moved {
+			if contextName == currentContext {
+				if err := manager.RelinkContext(currentContext); err != nil {
+					errors = append(errors, fmt.Sprintf("%s: failed to relink dependencies: %v", currentContext, err))
+				}
+				break
+			}
+		}
+		fmt.Printf("\n✅ Migrated %d worktrees\n", len(moved))
+		fmt.Println("Other contexts are relinked the next time you switch to them.")
+	}
+
+	if len(errors) > 0 {
+		fmt.Printf("❌ Failed to migrate %d worktrees:\n", len(errors))
+		for _, err := range errors {
+			fmt.Printf("  %s\n", err)
+		}
+		return fmt.Errorf("worktree migration failed for some repositories")
+	}
+
+	return nil
+}
+
+// printRemoteTopology shows where a repository fetches from and pushes to,
+// and how the current branch is tracked
+func (c *DiagnoseCmd) printRemoteTopology(gitRepo *git.GitRepo, repo *config.Repository, mainBranch, currentBranch string) {
+	describe := func(remote string) string {
+		url, err := gitRepo.GetRemoteURL(remote)
+		if err != nil {
+			return fmt.Sprintf("%s (❌ remote not found)", remote)
+		}
+		return fmt.Sprintf("%s (%s)", remote, url)
+	}
+
+	if repo.IsTriangular() {
+		fmt.Printf("   🔀 Fork workflow: pull from %s, push to %s\n", describe(repo.GetRemote()), describe(repo.GetPushRemote()))
+		fmt.Printf("      Branches rebase onto %s/%s and are pushed to %s\n", repo.GetRemote(), mainBranch, repo.GetPushRemote())
+	} else {
+		fmt.Printf("   🔗 Remote: %s\n", describe(repo.GetRemote()))
+	}
+
+	if currentBranch == "" {
+		return
+	}
+
+	tracking := gitRepo.GetBranchTracking(currentBranch)
+	if tracking.Remote == "" {
+		return
+	}
+
+	pushRemote := tracking.PushRemote
+	if pushRemote == "" {
+		pushRemote = tracking.Remote
+	}
+	fmt.Printf("   📡 Tracking: pulls %s/%s, pushes to %s\n", tracking.Remote, tracking.Merge, pushRemote)
+
+	if repo.IsTriangular() && (tracking.Remote != repo.GetRemote() || tracking.PushRemote != repo.GetPushRemote()) {
+		fmt.Printf("   ⚠️  Tracking does not match the fork workflow. Run 'alfred push' to fix it\n")
+	}
+}
+
 // Version information
 var (
 	version   = "dev"
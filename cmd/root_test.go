@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/viniciusamelio/alfred/internal/config"
+	"github.com/viniciusamelio/alfred/internal/scan"
+	"github.com/viniciusamelio/alfred/internal/testutil"
+)
+
+func TestScanCmd_RunMerge(t *testing.T) {
+	testutil.SetGitIdentity(t)
+
+	workspace := t.TempDir()
+	testutil.Chdir(t, workspace)
+
+	testutil.InitRepo(t, filepath.Join(workspace, "app"), "main", map[string]string{"pubspec.yaml": "name: app\n"})
+	testutil.InitRepo(t, filepath.Join(workspace, "core"), "main", map[string]string{"pubspec.yaml": "name: core\n"})
+	// A new package named like a registered one
+	testutil.InitRepo(t, filepath.Join(workspace, "tools", "core"), "main", map[string]string{"pubspec.yaml": "name: core\n"})
+
+	// legacy is gone from disk; old only holds legacy
+	testutil.WriteFile(t, workspace, filepath.Join(config.AlfredDir, config.ConfigFileName), `repos:
+  - name: app
+    path: ./app
+  - name: core
+    path: ./core
+  - name: legacy
+    path: ./legacy
+master: app
+contexts:
+  feat:
+    - app
+    - legacy
+  old:
+    - legacy
+`)
+
+	merge := &ScanCmd{Merge: true, Depth: 2, NonInteractive: true}
+	if err := merge.runMerge(); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(cfg.GetRepoAliases(), ","); got != "app,core,legacy,tools-core" {
+		t.Errorf("Expected tools/core to be added under an alias and legacy to be kept, got %s", got)
+	}
+	if repo, err := cfg.GetRepoByAlias("tools-core"); err != nil || repo.Name != "core" {
+		t.Errorf("Expected tools-core to keep the package name, got %+v (%v)", repo, err)
+	}
+	if !cfg.ContextExists("old") {
+		t.Error("Expected old to be kept without --force")
+	}
+
+	merge.Force = true
+	if err := merge.runMerge(); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err = config.LoadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(cfg.GetRepoAliases(), ","); got != "app,core,tools-core" {
+		t.Errorf("Expected legacy to be removed, got %s", got)
+	}
+	if cfg.ContextExists("old") {
+		t.Error("Expected old to be deleted with --force")
+	}
+	if got := strings.Join(cfg.Contexts["feat"], ","); got != "app" {
+		t.Errorf("Expected feat to keep app only, got %s", got)
+	}
+}
+
+func TestScanCmd_CreateAlfredConfigAliasesDuplicates(t *testing.T) {
+	testutil.Chdir(t, t.TempDir())
+
+	repos := []scan.RepoInfo{
+		{Package: scan.Package{Name: "app", Path: "./app"}, MainBranch: "main"},
+		{Package: scan.Package{Name: "example", Path: "./app/example"}, MainBranch: "main"},
+		{Package: scan.Package{Name: "example", Path: "./core/example"}, MainBranch: "main"},
+	}
+	if _, err := (&ScanCmd{}).createAlfredConfig(repos, 2); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		t.Fatalf("Expected a valid configuration, got %v", err)
+	}
+	if got := strings.Join(cfg.GetRepoAliases(), ","); got != "app,example,core-example" {
+		t.Errorf("Expected the second example to get an alias, got %s", got)
+	}
+	if cfg.Master != "core-example" {
+		t.Errorf("Expected the master to use the alias, got %s", cfg.Master)
+	}
+}
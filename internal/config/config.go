@@ -4,22 +4,47 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
 
+	"github.com/charmbracelet/log"
 	"gopkg.in/yaml.v3"
 )
 
 type Config struct {
-	Repos      []Repository        `yaml:"repos"`
-	Master     string              `yaml:"master"`
-	Mode       string              `yaml:"mode"`
-	MainBranch string              `yaml:"main_branch,omitempty"`
-	Contexts   map[string][]string `yaml:"contexts"`
+	Version         int                 `yaml:"version,omitempty"`
+	Repos           []Repository        `yaml:"repos,omitempty"`
+	Groups          map[string][]string `yaml:"groups,omitempty"`
+	Master          string              `yaml:"master,omitempty"`
+	Mode            string              `yaml:"mode,omitempty"`
+	MainBranch      string              `yaml:"main_branch,omitempty"`
+	BranchTemplate  string              `yaml:"branch_template,omitempty"`
+	CommitTemplate  string              `yaml:"commit_template,omitempty"`
+	ContextBranches map[string]string   `yaml:"context_branches,omitempty"`
+	WorktreeDir     string              `yaml:"worktree_dir,omitempty"`
+	// Contexts and their metadata are written together, see MarshalYAML
+	Contexts     map[string][]string    `yaml:"-"`
+	ContextInfos map[string]ContextInfo `yaml:"-"`
+
+	// manifest is the team manifest as loaded, nil when there is none
+	manifest       *Config
+	sharedContexts map[string]bool
+	// locator finds problems in the files the configuration was loaded from
+	locator *locator
+	// root is the absolute workspace directory the configuration was loaded from
+	root string
 }
 
 type Repository struct {
-	Name  string `yaml:"name"`
-	Alias string `yaml:"alias,omitempty"`
-	Path  string `yaml:"path"`
+	Name       string `yaml:"name"`
+	Alias      string `yaml:"alias,omitempty"`
+	Path       string `yaml:"path"`
+	URL        string `yaml:"url,omitempty"`
+	MainBranch string `yaml:"main_branch,omitempty"`
+	Remote     string `yaml:"remote,omitempty"`
+	PushRemote string `yaml:"push_remote,omitempty"`
 }
 
 const (
@@ -29,12 +54,29 @@ const (
 	ModeBranch   = "branch"
 	ModeWorktree = "worktree"
 	DefaultMode  = ModeWorktree
+
+	DefaultBranchTemplate = "{context}"
+	DefaultRemote         = "origin"
 )
 
+var ticketPattern = regexp.MustCompile(`[A-Za-z][A-Za-z0-9]*-[0-9]+`)
+
 func getAlfredDir() string {
 	return filepath.Join(".", AlfredDir)
 }
 
+// WorkspaceRoot returns the absolute directory holding the configuration, the
+// current directory for a configuration that was not loaded from a file
+func (c *Config) WorkspaceRoot() string {
+	if c.root != "" {
+		return c.root
+	}
+	if wd, err := os.Getwd(); err == nil {
+		return wd
+	}
+	return "."
+}
+
 func getConfigPath() string {
 	return filepath.Join(getAlfredDir(), ConfigFileName)
 }
@@ -49,21 +91,54 @@ func ensureAlfredDir() error {
 	return nil
 }
 
+// LoadConfig loads and validates the configuration, returning a
+// *ValidationError listing every problem found. Warnings are logged and do
+// not stop the command; 'alfred config validate' still fails on them.
 func LoadConfig() (*Config, error) {
-	configPath := getConfigPath()
+	config, err := ReadConfig()
+	if err != nil {
+		return nil, err
+	}
 
-	if _, err := os.Stat(configPath); os.IsNotExist(err) {
-		return nil, fmt.Errorf("alfred.yaml not found in .alfred directory. Run 'alfred init' to initialize")
+	problems := config.Locate(config.Validate())
+	if errs := Errors(problems); len(errs) > 0 {
+		return nil, &ValidationError{Problems: errs}
+	}
+	for _, problem := range problems {
+		log.Warnf("Configuration: %s", problem.Error())
 	}
+	return config, nil
+}
 
-	data, err := os.ReadFile(configPath)
+// ReadConfig loads the configuration without validating it, so that an
+// invalid configuration can still be inspected and fixed
+func ReadConfig() (*Config, error) {
+	manifest, err := readConfigFile(getManifestPath())
 	if err != nil {
-		return nil, fmt.Errorf("failed to read config file: %w", err)
+		return nil, err
+	}
+	local, err := readConfigFile(getConfigPath())
+	if err != nil {
+		return nil, err
 	}
 
-	var config Config
-	if err := yaml.Unmarshal(data, &config); err != nil {
-		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	if manifest == nil && local == nil {
+		return nil, fmt.Errorf("alfred.yaml not found in the current directory or the .alfred directory. Run 'alfred init' to initialize")
+	}
+
+	var config *Config
+	if manifest != nil {
+		var localConfig *Config
+		if local != nil {
+			localConfig = local.config
+		}
+		config = mergeConfigs(manifest.config, localConfig)
+	} else {
+		config = local.config
+	}
+	config.locator = newLocator(manifest, local, config)
+	if config.root, err = filepath.Abs("."); err != nil {
+		return nil, fmt.Errorf("failed to get workspace directory: %w", err)
 	}
 
 	// Set default mode if not specified
@@ -71,41 +146,127 @@ func LoadConfig() (*Config, error) {
 		config.Mode = DefaultMode
 	}
 
-	// Validate mode
-	if config.Mode != ModeBranch && config.Mode != ModeWorktree {
-		return nil, fmt.Errorf("invalid mode '%s'. Must be 'branch' or 'worktree'", config.Mode)
-	}
-
 	// Set default main branch if not specified
 	if config.MainBranch == "" {
 		config.MainBranch = "main"
 	}
 
-	return &config, nil
+	return config, nil
 }
 
+// Save writes the configuration. With a team manifest, shared contexts and
+// settings go to the manifest and everything else to the local state file.
 func (c *Config) Save() error {
 	if err := ensureAlfredDir(); err != nil {
 		return err
 	}
 
-	data, err := yaml.Marshal(c)
+	// Saving writes the current format, which older alfred versions may not
+	// read, so files of an older version are backed up first like
+	// 'alfred config migrate' does
+	upgrades, err := PlanMigrations()
+	if err != nil {
+		return err
+	}
+	for _, upgrade := range upgrades {
+		if err := upgrade.backup(); err != nil {
+			return err
+		}
+	}
+	c.Version = CurrentVersion
+
+	local := c
+	if c.manifest != nil {
+		manifest, personal := c.splitConfig()
+		if err := saveManifest(manifest, c.manifest); err != nil {
+			return err
+		}
+		c.manifest = manifest
+		local = personal
+	}
+
+	data, err := yaml.Marshal(local)
 	if err != nil {
 		return fmt.Errorf("failed to marshal config: %w", err)
 	}
 
 	configPath := getConfigPath()
-	if err := os.WriteFile(configPath, data, 0644); err != nil {
+	if err := writeFileAtomic(configPath, data); err != nil {
 		return fmt.Errorf("failed to write config file: %w", err)
 	}
 
+	for _, upgrade := range upgrades {
+		log.Infof("Upgraded %s to configuration version %d, the previous version is kept in %s",
+			upgrade.File, CurrentVersion, upgrade.Backup)
+	}
 	return nil
 }
 
+// writeFileAtomic writes data to a temporary file next to path and renames it
+// over path, so readers never see a partially written file
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = os.Remove(tmp.Name())
+	}()
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(0644); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Clone returns a deep copy of the configuration that can be edited and saved
+// on its own
+func (c *Config) Clone() *Config {
+	clone := *c
+	clone.Repos = append([]Repository(nil), c.Repos...)
+
+	clone.Contexts = make(map[string][]string, len(c.Contexts))
+	for name, repos := range c.Contexts {
+		clone.Contexts[name] = append([]string(nil), repos...)
+	}
+	if c.Groups != nil {
+		clone.Groups = make(map[string][]string, len(c.Groups))
+		for name, members := range c.Groups {
+			clone.Groups[name] = append([]string(nil), members...)
+		}
+	}
+	if c.ContextInfos != nil {
+		clone.ContextInfos = make(map[string]ContextInfo, len(c.ContextInfos))
+		for name, info := range c.ContextInfos {
+			clone.ContextInfos[name] = info
+		}
+	}
+	if c.ContextBranches != nil {
+		clone.ContextBranches = make(map[string]string, len(c.ContextBranches))
+		for name, branch := range c.ContextBranches {
+			clone.ContextBranches[name] = branch
+		}
+	}
+	if c.sharedContexts != nil {
+		clone.sharedContexts = make(map[string]bool, len(c.sharedContexts))
+		for name, shared := range c.sharedContexts {
+			clone.sharedContexts[name] = shared
+		}
+	}
+	return &clone
+}
+
 func (c *Config) GetRepoByAlias(alias string) (*Repository, error) {
 	for _, repo := range c.Repos {
-		// Check alias first (if set), then name
-		if (repo.Alias != "" && repo.Alias == alias) || (repo.Alias == "" && repo.Name == alias) {
+		if repo.Identifier() == alias {
 			return &repo, nil
 		}
 	}
@@ -122,11 +283,16 @@ func (c *Config) GetContextRepos(contextName string) ([]*Repository, error) {
 		return repos, nil
 	}
 
-	aliases, exists := c.Contexts[contextName]
+	refs, exists := c.Contexts[contextName]
 	if !exists {
 		return nil, fmt.Errorf("context '%s' not found", contextName)
 	}
 
+	aliases, err := c.ExpandRepos(refs)
+	if err != nil {
+		return nil, fmt.Errorf("context '%s': %w", contextName, err)
+	}
+
 	var repos []*Repository
 	for _, alias := range aliases {
 		repo, err := c.GetRepoByAlias(alias)
@@ -156,21 +322,14 @@ func (c *Config) AddContext(name string, repoAliases []string) error {
 		c.Contexts = make(map[string][]string)
 	}
 
-	// Validate that all repo aliases exist
-	for _, alias := range repoAliases {
-		found := false
-		for _, repo := range c.Repos {
-			// Check both alias and name
-			if (repo.Alias != "" && repo.Alias == alias) || (repo.Alias == "" && repo.Name == alias) {
-				found = true
-				break
-			}
-		}
-		if !found {
-			return fmt.Errorf("repository alias '%s' not found", alias)
-		}
+	// Validate that all repo aliases and groups exist
+	if _, err := c.ExpandRepos(repoAliases); err != nil {
+		return err
 	}
 
+	if !c.ContextExists(name) {
+		c.SetContextInfo(name, c.newContextInfo(name))
+	}
 	c.Contexts[name] = repoAliases
 	return nil
 }
@@ -193,6 +352,165 @@ func (c *Config) RemoveContext(name string) error {
 
 	// Remove the context
 	delete(c.Contexts, name)
+	delete(c.ContextInfos, name)
+	delete(c.ContextBranches, name)
+	delete(c.sharedContexts, name)
+	return nil
+}
+
+// AddRepo registers a new repository, whose alias or name must not be taken
+func (c *Config) AddRepo(repo Repository) error {
+	if indexOfRepo(c.Repos, repo.Identifier()) >= 0 {
+		return fmt.Errorf("repository '%s' already exists", repo.Identifier())
+	}
+	c.Repos = append(c.Repos, repo)
+	return nil
+}
+
+// UpdateRepo replaces the repository identified by alias. When its alias or
+// name changes, the master setting and the contexts referencing it follow; the
+// new alias or name must not be taken by another repository.
+func (c *Config) UpdateRepo(alias string, repo Repository) error {
+	i := indexOfRepo(c.Repos, alias)
+	if i < 0 {
+		return fmt.Errorf("repository with alias '%s' not found", alias)
+	}
+
+	newAlias := repo.Identifier()
+	if newAlias != alias && indexOfRepo(c.Repos, newAlias) >= 0 {
+		return fmt.Errorf("repository '%s' already exists", newAlias)
+	}
+
+	c.Repos[i] = repo
+	if newAlias == alias {
+		return nil
+	}
+
+	if c.Master == alias {
+		c.Master = newAlias
+	}
+	for _, refs := range []map[string][]string{c.Contexts, c.Groups} {
+		for name, aliases := range refs {
+			for j, existing := range aliases {
+				if existing == alias {
+					refs[name][j] = newAlias
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// ContextsOnlyWith returns the contexts whose only repository is alias, which
+// removing the repository would leave empty
+func (c *Config) ContextsOnlyWith(alias string) []string {
+	var names []string
+	for name := range c.Contexts {
+		if aliases := c.GetContextAliases(name); len(aliases) == 1 && aliases[0] == alias {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// RemoveRepo unregisters a repository and removes it from every context. It
+// refuses to leave a context without repositories: such contexts must be
+// deleted first, so their worktrees and branches go with them.
+func (c *Config) RemoveRepo(alias string) error {
+	i := indexOfRepo(c.Repos, alias)
+	if i < 0 {
+		return fmt.Errorf("repository with alias '%s' not found", alias)
+	}
+	if emptied := c.ContextsOnlyWith(alias); len(emptied) > 0 {
+		return fmt.Errorf("'%s' is the only repository of contexts %s. Delete them first with 'alfred delete'", alias, strings.Join(emptied, ", "))
+	}
+	c.Repos = append(c.Repos[:i], c.Repos[i+1:]...)
+
+	if c.Master == alias {
+		c.Master = ""
+	}
+	c.removeFromGroups(alias)
+
+	for name, aliases := range c.Contexts {
+		if remaining := withoutRef(aliases, alias); len(remaining) != len(aliases) {
+			c.Contexts[name] = remaining
+		}
+	}
+	return nil
+}
+
+// AddRepoToContext adds a repository to an existing context
+func (c *Config) AddRepoToContext(contextName, alias string) error {
+	if !c.ContextExists(contextName) {
+		return fmt.Errorf("context '%s' does not exist", contextName)
+	}
+	if _, err := c.GetRepoByAlias(alias); err != nil {
+		return err
+	}
+	if c.ContextContainsRepo(contextName, alias) {
+		return fmt.Errorf("repository '%s' is already in context '%s'", alias, contextName)
+	}
+
+	c.Contexts[contextName] = append(c.Contexts[contextName], alias)
+	return nil
+}
+
+// RemoveRepoFromContext removes a repository from a context, which must keep
+// at least one. A repository included through a group replaces the group
+// references of the context with the repositories they hold.
+func (c *Config) RemoveRepoFromContext(contextName, alias string) error {
+	if !c.ContextContainsRepo(contextName, alias) {
+		return fmt.Errorf("repository '%s' is not in context '%s'", alias, contextName)
+	}
+	aliases := c.GetContextAliases(contextName)
+	if len(aliases) == 1 {
+		return fmt.Errorf("cannot remove the last repository of context '%s'. Delete the context instead", contextName)
+	}
+
+	refs := withoutRef(c.Contexts[contextName], alias)
+	if expanded, err := c.ExpandRepos(refs); err == nil && slices.Contains(expanded, alias) {
+		refs = withoutRef(aliases, alias)
+	}
+	c.Contexts[contextName] = refs
+	return nil
+}
+
+// ContextContainsRepo checks whether a repository alias is part of a context,
+// directly or through a group
+func (c *Config) ContextContainsRepo(contextName, alias string) bool {
+	return slices.Contains(c.GetContextAliases(contextName), alias)
+}
+
+// RenameContext moves a context, and its branch override if any, to a new name
+func (c *Config) RenameContext(oldName, newName string) error {
+	if !c.ContextExists(oldName) {
+		return fmt.Errorf("context '%s' does not exist", oldName)
+	}
+	if c.ContextExists(newName) {
+		return fmt.Errorf("context '%s' already exists", newName)
+	}
+	if oldName == "main" || oldName == "master" || newName == "main" || newName == "master" {
+		return fmt.Errorf("cannot rename built-in context")
+	}
+
+	c.Contexts[newName] = c.Contexts[oldName]
+	delete(c.Contexts, oldName)
+
+	if c.sharedContexts[oldName] {
+		c.sharedContexts[newName] = true
+		delete(c.sharedContexts, oldName)
+	}
+
+	if info, ok := c.ContextInfos[oldName]; ok {
+		c.ContextInfos[newName] = info
+		delete(c.ContextInfos, oldName)
+	}
+
+	if branch, ok := c.ContextBranches[oldName]; ok {
+		c.ContextBranches[newName] = branch
+		delete(c.ContextBranches, oldName)
+	}
 	return nil
 }
 
@@ -207,29 +525,13 @@ func (c *Config) IsContextContainsMaster(contextName string) bool {
 	}
 
 	// Check if master alias is in the context's repository list
-	contextRepos, exists := c.Contexts[contextName]
-	if !exists {
-		return false
-	}
-
-	for _, alias := range contextRepos {
-		if alias == c.Master {
-			return true
-		}
-	}
-
-	return false
+	return c.ContextContainsRepo(contextName, c.Master)
 }
 
 func (c *Config) GetRepoAliases() []string {
 	aliases := make([]string, len(c.Repos))
 	for i, repo := range c.Repos {
-		// Return alias if set, otherwise return name
-		if repo.Alias != "" {
-			aliases[i] = repo.Alias
-		} else {
-			aliases[i] = repo.Name
-		}
+		aliases[i] = repo.Identifier()
 	}
 	return aliases
 }
@@ -257,10 +559,7 @@ func (c *Config) GetNonMasterReposForContext(contextName string) ([]*Repository,
 
 	var nonMasterRepos []*Repository
 	for _, repo := range contextRepos {
-		repoIdentifier := repo.Alias
-		if repoIdentifier == "" {
-			repoIdentifier = repo.Name
-		}
+		repoIdentifier := repo.Identifier()
 		if repoIdentifier != c.Master {
 			nonMasterRepos = append(nonMasterRepos, repo)
 		}
@@ -277,6 +576,22 @@ func (c *Config) IsWorktreeMode() bool {
 	return c.Mode == ModeWorktree
 }
 
+// GetContextMode returns the mode of a context: its own mode when it sets
+// one, otherwise the global mode. The main context always follows the
+// global mode.
+func (c *Config) GetContextMode(contextName string) string {
+	if mode := c.ContextInfos[contextName].Mode; mode != "" {
+		return mode
+	}
+	return c.Mode
+}
+
+// IsContextBranchMode reports whether a context checks its branches out in
+// the original repository paths rather than in worktrees
+func (c *Config) IsContextBranchMode(contextName string) bool {
+	return c.GetContextMode(contextName) == ModeBranch
+}
+
 // GetMainBranch returns the configured main branch name
 func (c *Config) GetMainBranch() string {
 	if c.MainBranch == "" {
@@ -285,8 +600,108 @@ func (c *Config) GetMainBranch() string {
 	return c.MainBranch
 }
 
+// GetRepoMainBranch returns the main branch of a repository, falling back to
+// the global main branch when the repository does not override it
+func (c *Config) GetRepoMainBranch(repo *Repository) string {
+	if repo.MainBranch != "" {
+		return repo.MainBranch
+	}
+	return c.GetMainBranch()
+}
+
+// Identifier returns the name contexts use for the repository: its alias, or
+// else its name
+func (r *Repository) Identifier() string {
+	if r.Alias != "" {
+		return r.Alias
+	}
+	return r.Name
+}
+
+// GetRemote returns the remote used to fetch and pull the repository
+func (r *Repository) GetRemote() string {
+	if r.Remote == "" {
+		return DefaultRemote
+	}
+	return r.Remote
+}
+
+// GetPushRemote returns the remote context branches are pushed to
+func (r *Repository) GetPushRemote() string {
+	if r.PushRemote == "" {
+		return r.GetRemote()
+	}
+	return r.PushRemote
+}
+
+// IsTriangular reports whether the repository pulls from one remote and pushes
+// to another, as in fork-based workflows
+func (r *Repository) IsTriangular() bool {
+	return r.GetPushRemote() != r.GetRemote()
+}
+
 // SetMainBranch sets the main branch name and saves the config
 func (c *Config) SetMainBranch(branchName string) error {
 	c.MainBranch = branchName
 	return c.Save()
 }
+
+// SetContextBranch pins the git branch of a context in context_branches
+func (c *Config) SetContextBranch(contextName, branch string) {
+	if c.ContextBranches == nil {
+		c.ContextBranches = make(map[string]string)
+	}
+	c.ContextBranches[contextName] = branch
+}
+
+// GetBranchName maps a context to the git branch used for it in every repository.
+// A per-context override in context_branches wins over branch_template.
+func (c *Config) GetBranchName(contextName string) string {
+	if contextName == "main" || contextName == "master" {
+		return c.GetMainBranch()
+	}
+
+	if branch, ok := c.ContextBranches[contextName]; ok && branch != "" {
+		return branch
+	}
+
+	template := c.BranchTemplate
+	if template == "" {
+		template = DefaultBranchTemplate
+	}
+
+	if branch := c.ExpandBranchTemplate(template, contextName); branch != "" {
+		return branch
+	}
+	return contextName
+}
+
+// ExpandBranchTemplate replaces the {context}, {user}, {ticket}, {owner} and
+// {description} variables in a branch template, taking the ticket from the
+// context metadata or else from the context name. Separators left dangling by
+// empty variables are removed.
+func (c *Config) ExpandBranchTemplate(template, contextName string) string {
+	vars := c.templateVars(contextName)
+	vars["owner"] = branchSafe(vars["owner"])
+	vars["description"] = branchSafe(vars["description"])
+	delete(vars, "base")
+	branch := expandTemplate(template, vars)
+
+	// Clean up separators around variables that expanded to nothing
+	for _, pair := range [][2]string{{"//", "/"}, {"--", "-"}, {"/-", "/"}, {"-/", "/"}} {
+		for strings.Contains(branch, pair[0]) {
+			branch = strings.ReplaceAll(branch, pair[0], pair[1])
+		}
+	}
+	return strings.Trim(branch, "-/")
+}
+
+// currentUser returns a branch-safe name for the local user
+func currentUser() string {
+	user := os.Getenv("USER")
+	if user == "" {
+		user = os.Getenv("USERNAME")
+	}
+	user = strings.ToLower(strings.TrimSpace(user))
+	return strings.Join(strings.Fields(user), "-")
+}
@@ -0,0 +1,558 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/viniciusamelio/alfred/internal/testutil"
+)
+
+func TestConfig_GetBranchName(t *testing.T) {
+	t.Setenv("USER", "Jane Doe")
+
+	tests := []struct {
+		name     string
+		config   Config
+		context  string
+		expected string
+	}{
+		{
+			name:     "default template uses context name",
+			config:   Config{},
+			context:  "feature-1",
+			expected: "feature-1",
+		},
+		{
+			name:     "ticket and context variables",
+			config:   Config{BranchTemplate: "feature/{ticket}-{context}"},
+			context:  "jira-123",
+			expected: "feature/JIRA-123-jira-123",
+		},
+		{
+			name:     "missing ticket leaves no dangling separators",
+			config:   Config{BranchTemplate: "feature/{ticket}-{context}"},
+			context:  "login",
+			expected: "feature/login",
+		},
+		{
+			name:     "user variable is branch safe",
+			config:   Config{BranchTemplate: "{user}/{context}"},
+			context:  "login",
+			expected: "jane-doe/login",
+		},
+		{
+			name: "per-context override wins",
+			config: Config{
+				BranchTemplate:  "feature/{context}",
+				ContextBranches: map[string]string{"login": "feature/JIRA-1-login-page"},
+			},
+			context:  "login",
+			expected: "feature/JIRA-1-login-page",
+		},
+		{
+			name: "metadata variables",
+			config: Config{
+				BranchTemplate: "{owner}/{ticket}-{description}",
+				ContextInfos:   map[string]ContextInfo{"login": {Ticket: "APP-7", Owner: "ana", Description: "New login page"}},
+			},
+			context:  "login",
+			expected: "ana/APP-7-new-login-page",
+		},
+		{
+			name:     "main context maps to main branch",
+			config:   Config{BranchTemplate: "feature/{context}", MainBranch: "develop"},
+			context:  "main",
+			expected: "develop",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.config.GetBranchName(tt.context); got != tt.expected {
+				t.Errorf("GetBranchName(%q) = %q, expected %q", tt.context, got, tt.expected)
+			}
+		})
+	}
+}
+
+// chdirTemp runs the test from a fresh directory, since the config files are
+// looked up relative to the working directory
+func chdirTemp(t *testing.T) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	testutil.Chdir(t, dir)
+	return dir
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+const teamManifest = `# Team workspace
+repos:
+  - name: app
+    path: ./app
+  - name: core
+    path: ./core
+    remote: upstream
+master: app
+mode: worktree
+main_branch: develop
+contexts:
+  payments:
+    - app
+    - core
+`
+
+func TestLoadConfig_MergesManifestAndLocalState(t *testing.T) {
+	chdirTemp(t)
+	writeFile(t, ManifestFileName, teamManifest)
+	writeFile(t, filepath.Join(AlfredDir, ConfigFileName), `repos:
+  - name: core
+    path: ../core-fork
+mode: branch
+contexts:
+  spike:
+    - core
+`)
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+
+	if cfg.Mode != ModeBranch {
+		t.Errorf("Expected local mode override, got %s", cfg.Mode)
+	}
+	if cfg.MainBranch != "develop" {
+		t.Errorf("Expected main branch from the manifest, got %s", cfg.MainBranch)
+	}
+
+	core, err := cfg.GetRepoByAlias("core")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if core.Path != "../core-fork" {
+		t.Errorf("Expected local repository override, got path %s", core.Path)
+	}
+
+	if !cfg.ContextExists("payments") || !cfg.IsSharedContext("payments") {
+		t.Error("Expected payments to be a shared context")
+	}
+	if !cfg.ContextExists("spike") || cfg.IsSharedContext("spike") {
+		t.Error("Expected spike to be a personal context")
+	}
+}
+
+func TestConfig_SaveSplitsManifestAndLocalState(t *testing.T) {
+	chdirTemp(t)
+	writeFile(t, ManifestFileName, teamManifest)
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+
+	// Personal changes must not touch the committed manifest
+	if err := cfg.AddContext("spike", []string{"core"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := cfg.Save(); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	data, err := os.ReadFile(ManifestFileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != teamManifest {
+		t.Errorf("Manifest was rewritten for a personal change:\n%s", data)
+	}
+
+	local, err := os.ReadFile(filepath.Join(AlfredDir, ConfigFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(local), "spike") || strings.Contains(string(local), "payments") {
+		t.Errorf("Expected only the personal context in the local state:\n%s", local)
+	}
+
+	// Changes to shared contexts go to the manifest
+	if err := cfg.AddRepoToContext("spike", "app"); err != nil {
+		t.Fatal(err)
+	}
+	if err := cfg.ShareContexts([]string{"spike"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := cfg.RenameContext("payments", "billing"); err != nil {
+		t.Fatal(err)
+	}
+	if err := cfg.Save(); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	reloaded, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if got := reloaded.SharedContextNames(); strings.Join(got, ",") != "billing,spike" {
+		t.Errorf("Expected billing and spike to be shared, got %v", got)
+	}
+	if reloaded.ContextExists("payments") {
+		t.Error("Expected payments to be renamed in the manifest")
+	}
+	if core, _ := reloaded.GetRepoByAlias("core"); core == nil || core.GetRemote() != "upstream" {
+		t.Error("Expected repository settings to be kept in the manifest")
+	}
+}
+
+func TestConfig_ContextMetadata(t *testing.T) {
+	chdirTemp(t)
+	writeFile(t, ManifestFileName, teamManifest+`  checkout:
+    repos: [app]
+    description: Checkout redesign
+    ticket: PAY-42
+    owner: ana
+`)
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if repos := cfg.Contexts["payments"]; len(repos) != 2 {
+		t.Errorf("Expected list-style contexts to keep loading, got %v", repos)
+	}
+	info := cfg.GetContextInfo("checkout")
+	if cfg.Contexts["checkout"][0] != "app" || info.Ticket != "PAY-42" || info.Owner != "ana" {
+		t.Fatalf("Expected the mapping-style context to be read, got %v %+v", cfg.Contexts["checkout"], info)
+	}
+
+	cfg.CommitTemplate = "[{ticket}] {description}"
+	if got := cfg.GetCommitMessage("checkout"); got != "[PAY-42] Checkout redesign" {
+		t.Errorf("Unexpected commit message %q", got)
+	}
+	if got := cfg.GetCommitMessage("payments"); got != "" {
+		t.Errorf("Expected empty variables to leave no brackets, got %q", got)
+	}
+
+	// Switching is recorded locally, even for shared contexts
+	manifest, err := os.ReadFile(ManifestFileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.MarkSwitched("checkout", time.Now())
+	if err := cfg.Save(); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if data, _ := os.ReadFile(ManifestFileName); string(data) != string(manifest) {
+		t.Errorf("Manifest was rewritten when switching:\n%s", data)
+	}
+	if local, _ := os.ReadFile(filepath.Join(AlfredDir, ConfigFileName)); !strings.Contains(string(local), "last_switched:") {
+		t.Errorf("Expected the switch in the local state:\n%s", local)
+	}
+
+	reloaded, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if info := reloaded.GetContextInfo("checkout"); info.LastSwitched.IsZero() || info.Description != "Checkout redesign" {
+		t.Errorf("Expected the metadata to survive a round trip, got %+v", info)
+	}
+}
+
+func TestLoadConfig_ReportsProblemPositions(t *testing.T) {
+	chdirTemp(t)
+	writeFile(t, ManifestFileName, teamManifest+"    - ui\n")
+	writeFile(t, filepath.Join(AlfredDir, ConfigFileName), `repos:
+  - name: ui
+    alias: app
+    path: ./ui
+master: mobile
+contexts:
+  release-1.2:
+    - app
+    - payments
+`)
+
+	_, err := LoadConfig()
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("Expected a ValidationError, got %v", err)
+	}
+
+	var got []string
+	for _, problem := range validationErr.Problems {
+		got = append(got, fmt.Sprintf("%s:%d:%d %s", problem.File, problem.Line, problem.Column, problem.Path))
+	}
+	local := filepath.Join(AlfredDir, ConfigFileName)
+	expected := []string{
+		local + ":5:9 master",
+		"alfred.yaml:15:7 contexts.payments[2]",
+		local + ":9:7 contexts.release-1.2[1]",
+	}
+	if strings.Join(got, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected problems at %v, got %v", expected, got)
+	}
+}
+
+func TestLoadConfig_AllowsWarnings(t *testing.T) {
+	chdirTemp(t)
+	writeFile(t, filepath.Join(AlfredDir, ConfigFileName), `repos:
+  - name: app
+    path: ./app
+master: app
+contexts:
+  login:
+    - app
+context_branches:
+  login: feature/login
+  gone: feature/gone
+`)
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("Expected a stale context branch not to stop loading, got %v", err)
+	}
+
+	if err := cfg.RemoveContext("login"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := cfg.ContextBranches["login"]; ok {
+		t.Error("Expected the branch of the removed context to be dropped")
+	}
+}
+
+func TestPlanMigrations(t *testing.T) {
+	chdirTemp(t)
+	writeFile(t, ManifestFileName, teamManifest)
+	writeFile(t, filepath.Join(AlfredDir, ConfigFileName), "version: 2\ncontexts: {}\n")
+
+	migrations, err := PlanMigrations()
+	if err != nil {
+		t.Fatalf("PlanMigrations failed: %v", err)
+	}
+	if len(migrations) != 1 || migrations[0].File != ManifestFileName || migrations[0].From != 1 {
+		t.Fatalf("Expected only the unversioned manifest to be migrated, got %+v", migrations)
+	}
+
+	diff := migrations[0].Diff()
+	if !strings.Contains(diff, "@@ -1,4 +1,5 @@\n # Team workspace\n+version: 2\n repos:") {
+		t.Errorf("Unexpected diff:\n%s", diff)
+	}
+
+	if err := migrations[0].Apply(); err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+	backup, err := os.ReadFile(migrations[0].Backup)
+	if err != nil || string(backup) != teamManifest {
+		t.Errorf("Expected the original manifest in the backup, got %q (%v)", backup, err)
+	}
+	if migrations, err := PlanMigrations(); err != nil || len(migrations) != 0 {
+		t.Errorf("Expected no migration after applying, got %v (%v)", migrations, err)
+	}
+
+	writeFile(t, ManifestFileName, "version: 99\n")
+	if _, err := LoadConfig(); err == nil || !strings.Contains(err.Error(), "newer than the version") {
+		t.Errorf("Expected a newer configuration to be refused, got %v", err)
+	}
+}
+
+func TestReadConfig_RecordsWorkspaceRoot(t *testing.T) {
+	root := chdirTemp(t)
+	writeFile(t, filepath.Join(AlfredDir, ConfigFileName), "repos:\n  - name: app\n    path: ./app\nmaster: app\n")
+
+	cfg, err := ReadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(AlfredDir); err != nil {
+		t.Fatal(err)
+	}
+	root, _ = filepath.EvalSymlinks(root)
+	if got, _ := filepath.EvalSymlinks(cfg.WorkspaceRoot()); got != root {
+		t.Errorf("Expected the workspace root %s, got %s", root, got)
+	}
+}
+
+func TestConfig_SaveBacksUpOlderVersions(t *testing.T) {
+	chdirTemp(t)
+	original := "repos:\n  - name: app\n    path: ./app\nmaster: app\n"
+	writeFile(t, filepath.Join(AlfredDir, ConfigFileName), original)
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cfg.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	backup, err := os.ReadFile(filepath.Join(AlfredDir, "alfred.v1.bak.yaml"))
+	if err != nil || string(backup) != original {
+		t.Errorf("Expected the version 1 file in the backup, got %q (%v)", backup, err)
+	}
+	if migrations, err := PlanMigrations(); err != nil || len(migrations) != 0 {
+		t.Errorf("Expected the saved file to record the current version, got %v (%v)", migrations, err)
+	}
+}
+
+func TestConfig_Validate(t *testing.T) {
+	cfg := &Config{
+		Repos: []Repository{
+			{Name: "app", Path: "./app"},
+			{Name: "core", Path: "./core"},
+			{Name: "core", Path: "./core-fork"},
+			{Name: "ui", Alias: "app", Path: "./ui"},
+			{Name: "legacy"},
+			{Name: "app", Alias: "shell", Path: "./shell"},
+		},
+		Master: "mobile",
+		Mode:   "trunk",
+		Contexts: map[string][]string{
+			"login": {"app", "payments", "app"},
+			"main":  {"core"},
+			"empty": {},
+		},
+		ContextBranches: map[string]string{"gone": "feature/gone"},
+		ContextInfos:    map[string]ContextInfo{"login": {Mode: "trunk"}},
+	}
+
+	var got, warnings []string
+	for _, problem := range cfg.Validate() {
+		got = append(got, problem.Path)
+		if problem.Warning {
+			warnings = append(warnings, problem.Path)
+		}
+	}
+	if strings.Join(warnings, ",") != "repos[5].name,contexts.login[2],context_branches.gone" {
+		t.Errorf("Expected the duplicates and the stale branch to be warnings, got %v", warnings)
+	}
+
+	expected := []string{
+		"repos[2].name",
+		"repos[3].alias",
+		"repos[4].path",
+		"repos[5].name",
+		"mode",
+		"master",
+		"contexts.empty",
+		"contexts.login",
+		"contexts.login[1]",
+		"contexts.login[2]",
+		"contexts.main",
+		"context_branches.gone",
+	}
+	if strings.Join(got, ",") != strings.Join(expected, ",") {
+		t.Errorf("Validate() problems at %v, expected %v", got, expected)
+	}
+
+	valid := &Config{
+		Repos:        []Repository{{Name: "app", Path: "./app"}, {Name: "core", Alias: "kernel", Path: "./core"}},
+		Master:       "app",
+		Mode:         ModeWorktree,
+		Contexts:     map[string][]string{"login": {"app", "kernel"}},
+		ContextInfos: map[string]ContextInfo{"login": {Mode: ModeBranch}},
+	}
+	if problems := valid.Validate(); len(problems) != 0 {
+		t.Errorf("Expected a valid configuration, got %v", problems)
+	}
+	if !valid.IsContextBranchMode("login") || valid.IsContextBranchMode("main") {
+		t.Error("Expected login to override the global mode and main to follow it")
+	}
+}
+
+func TestConfig_UpdateRepo(t *testing.T) {
+	cfg := &Config{
+		Repos:    []Repository{{Name: "app", Path: "./app"}, {Name: "core", Path: "./core"}},
+		Master:   "app",
+		Contexts: map[string][]string{"login": {"app", "core"}},
+	}
+
+	if err := cfg.UpdateRepo("app", Repository{Name: "app", Alias: "mobile", Path: "./app"}); err != nil {
+		t.Fatalf("UpdateRepo failed: %v", err)
+	}
+	if cfg.Master != "mobile" || cfg.Contexts["login"][0] != "mobile" {
+		t.Errorf("Expected references to follow the new alias, got master %s and context %v", cfg.Master, cfg.Contexts["login"])
+	}
+
+	if err := cfg.UpdateRepo("core", Repository{Name: "core", Alias: "mobile", Path: "./core"}); err == nil {
+		t.Error("Expected an alias already in use to be refused")
+	}
+}
+
+func TestConfig_Groups(t *testing.T) {
+	cfg := &Config{
+		Repos: []Repository{
+			{Name: "app", Path: "./app"},
+			{Name: "sdk", Path: "./sdk"},
+			{Name: "checkout", Path: "./checkout"},
+			{Name: "tokens", Path: "./tokens"},
+		},
+		Groups: map[string][]string{
+			"payments": {"sdk", "checkout"},
+			"all":      {"@payments", "tokens", "sdk"},
+			"loop":     {"@loop"},
+		},
+	}
+
+	aliases, err := cfg.ExpandRepos([]string{"@all", "app"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(aliases, ",") != "sdk,checkout,tokens,app" {
+		t.Errorf("Unexpected expansion %v", aliases)
+	}
+	if _, err := cfg.ExpandRepos([]string{"@loop"}); err == nil {
+		t.Error("Expected a group including itself to be refused")
+	}
+	if err := cfg.AddContext("broken", []string{"@unknown"}); err == nil {
+		t.Error("Expected an unknown group to be refused")
+	}
+
+	if err := cfg.AddContext("pay", []string{"@payments", "app"}); err != nil {
+		t.Fatal(err)
+	}
+	repos, err := cfg.GetContextRepos("pay")
+	if err != nil || len(repos) != 3 || !cfg.ContextContainsRepo("pay", "checkout") {
+		t.Fatalf("Expected the group to be expanded, got %v (%v)", repos, err)
+	}
+
+	// Removing a repository of a group keeps the rest of the group
+	if err := cfg.RemoveRepoFromContext("pay", "sdk"); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(cfg.Contexts["pay"], ","); got != "checkout,app" {
+		t.Errorf("Expected the group to be replaced by its other repositories, got %s", got)
+	}
+
+	// Unregistering the last repositories of a group removes it
+	cfg.Contexts["tokens"] = []string{"@all"}
+	delete(cfg.Groups, "loop")
+	for _, alias := range []string{"sdk", "checkout"} {
+		if err := cfg.RemoveRepo(alias); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, ok := cfg.Groups["payments"]; ok {
+		t.Error("Expected the emptied payments group to be removed")
+	}
+	if got := strings.Join(cfg.Groups["all"], ","); got != "tokens" {
+		t.Errorf("Expected all to keep tokens only, got %s", got)
+	}
+	if problems := cfg.Validate(); len(problems) != 0 {
+		t.Errorf("Expected a valid configuration, got %v", problems)
+	}
+}
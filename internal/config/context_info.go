@@ -0,0 +1,199 @@
+package config
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ContextInfo describes a context. It is written next to the repositories of
+// the context, except LastSwitched: switching is local state, so it is kept in
+// .alfred/alfred.yaml under last_switched, even for shared contexts.
+type ContextInfo struct {
+	Description string `yaml:"description,omitempty"`
+	Ticket      string `yaml:"ticket,omitempty"`
+	Owner       string `yaml:"owner,omitempty"`
+	Base        string `yaml:"base,omitempty"`
+	// Mode overrides the global mode for this context
+	Mode         string    `yaml:"mode,omitempty"`
+	Created      time.Time `yaml:"created,omitempty"`
+	LastSwitched time.Time `yaml:"-"`
+}
+
+func (i ContextInfo) isEmpty() bool {
+	return i.Description == "" && i.Ticket == "" && i.Owner == "" && i.Base == "" && i.Mode == "" && i.Created.IsZero()
+}
+
+// GetContextInfo returns the metadata of a context, empty if it has none
+func (c *Config) GetContextInfo(contextName string) ContextInfo {
+	return c.ContextInfos[contextName]
+}
+
+// SetContextInfo replaces the metadata of a context
+func (c *Config) SetContextInfo(contextName string, info ContextInfo) {
+	if c.ContextInfos == nil {
+		c.ContextInfos = make(map[string]ContextInfo)
+	}
+	c.ContextInfos[contextName] = info
+}
+
+// MarkSwitched records when a context was last switched to
+func (c *Config) MarkSwitched(contextName string, at time.Time) {
+	if !c.ContextExists(contextName) {
+		return
+	}
+	info := c.GetContextInfo(contextName)
+	info.LastSwitched = at.Truncate(time.Second)
+	c.SetContextInfo(contextName, info)
+}
+
+// newContextInfo is the metadata of a context created now: its ticket is
+// taken from the name when it contains one and its base is the main branch
+func (c *Config) newContextInfo(contextName string) ContextInfo {
+	return ContextInfo{
+		Ticket:  strings.ToUpper(ticketPattern.FindString(contextName)),
+		Owner:   currentUser(),
+		Base:    c.GetMainBranch(),
+		Created: time.Now().Truncate(time.Second),
+	}
+}
+
+// contextEntry is how a context is written: the list of its repositories, or
+// a mapping with the repositories and the metadata when it has any
+type contextEntry struct {
+	Repos []string
+	Info  ContextInfo
+}
+
+type contextMapping struct {
+	Repos       []string `yaml:"repos"`
+	ContextInfo `yaml:",inline"`
+}
+
+func (e *contextEntry) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.MappingNode {
+		return node.Decode(&e.Repos)
+	}
+
+	var mapping contextMapping
+	if err := node.Decode(&mapping); err != nil {
+		return err
+	}
+	e.Repos, e.Info = mapping.Repos, mapping.ContextInfo
+	return nil
+}
+
+func (e contextEntry) MarshalYAML() (interface{}, error) {
+	if e.Info.isEmpty() {
+		return e.Repos, nil
+	}
+	return contextMapping{Repos: e.Repos, ContextInfo: e.Info}, nil
+}
+
+// plainConfig has the fields of Config without its YAML methods
+type plainConfig Config
+
+// configDocument is the file layout of Config, where the metadata of each
+// context is written with its repositories
+type configDocument struct {
+	plainConfig  `yaml:",inline"`
+	Contexts     map[string]contextEntry `yaml:"contexts"`
+	LastSwitched map[string]time.Time    `yaml:"last_switched,omitempty"`
+}
+
+func (c *Config) UnmarshalYAML(node *yaml.Node) error {
+	var doc configDocument
+	if err := node.Decode(&doc); err != nil {
+		return err
+	}
+
+	*c = Config(doc.plainConfig)
+	if doc.Contexts != nil {
+		c.Contexts = make(map[string][]string, len(doc.Contexts))
+	}
+	for name, entry := range doc.Contexts {
+		c.Contexts[name] = entry.Repos
+		if !entry.Info.isEmpty() {
+			c.SetContextInfo(name, entry.Info)
+		}
+	}
+	for name, at := range doc.LastSwitched {
+		info := c.GetContextInfo(name)
+		info.LastSwitched = at
+		c.SetContextInfo(name, info)
+	}
+	return nil
+}
+
+func (c Config) MarshalYAML() (interface{}, error) {
+	doc := configDocument{plainConfig: plainConfig(c)}
+	if c.Contexts != nil {
+		doc.Contexts = make(map[string]contextEntry, len(c.Contexts))
+	}
+	for name, repos := range c.Contexts {
+		doc.Contexts[name] = contextEntry{Repos: repos, Info: c.ContextInfos[name]}
+	}
+	for name, info := range c.ContextInfos {
+		if info.LastSwitched.IsZero() {
+			continue
+		}
+		if doc.LastSwitched == nil {
+			doc.LastSwitched = make(map[string]time.Time)
+		}
+		doc.LastSwitched[name] = info.LastSwitched
+	}
+	return doc, nil
+}
+
+// templateVars returns the variables available in branch and commit templates
+// for a context
+func (c *Config) templateVars(contextName string) map[string]string {
+	info := c.GetContextInfo(contextName)
+
+	ticket := info.Ticket
+	if ticket == "" {
+		ticket = strings.ToUpper(ticketPattern.FindString(contextName))
+	}
+
+	return map[string]string{
+		"context":     contextName,
+		"user":        currentUser(),
+		"ticket":      ticket,
+		"description": info.Description,
+		"owner":       info.Owner,
+		"base":        info.Base,
+	}
+}
+
+var unsafeBranchChars = regexp.MustCompile(`[^a-z0-9._-]+`)
+
+// branchSafe turns free text such as a description into a branch name segment
+func branchSafe(value string) string {
+	return strings.Trim(unsafeBranchChars.ReplaceAllString(strings.ToLower(value), "-"), "-")
+}
+
+func expandTemplate(template string, vars map[string]string) string {
+	var pairs []string
+	for name, value := range vars {
+		pairs = append(pairs, "{"+name+"}", value)
+	}
+	return strings.NewReplacer(pairs...).Replace(template)
+}
+
+// GetCommitMessage returns the commit message suggested for a context by
+// commit_template, empty when there is no template
+func (c *Config) GetCommitMessage(contextName string) string {
+	if c.CommitTemplate == "" || contextName == "" || contextName == "main" || contextName == "master" {
+		return ""
+	}
+
+	message := expandTemplate(c.CommitTemplate, c.templateVars(contextName))
+
+	// Drop brackets and separators left around variables that expanded to nothing
+	for _, empty := range []string{"[]", "()"} {
+		message = strings.ReplaceAll(message, empty, "")
+	}
+	return strings.TrimLeft(message, " :-/")
+}
@@ -0,0 +1,130 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// GroupPrefix marks a reference to a group of repositories, e.g. "@payments",
+// wherever repository aliases are accepted
+const GroupPrefix = "@"
+
+var errGroupCycle = errors.New("includes itself")
+
+// IsGroupRef reports whether ref names a group rather than a repository
+func IsGroupRef(ref string) bool {
+	return strings.HasPrefix(ref, GroupPrefix)
+}
+
+// GetGroupNames returns the sorted names of the groups, without the prefix
+func (c *Config) GetGroupNames() []string {
+	var names []string
+	for name := range c.Groups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ExpandRepos resolves group references, which may contain other groups, into
+// repository aliases. Aliases keep the order they first appear in and are
+// listed once.
+func (c *Config) ExpandRepos(refs []string) ([]string, error) {
+	var aliases []string
+	seen := make(map[string]bool)
+	if err := c.expandRepos(refs, nil, seen, &aliases); err != nil {
+		return nil, err
+	}
+	return aliases, nil
+}
+
+func (c *Config) expandRepos(refs, groups []string, seen map[string]bool, aliases *[]string) error {
+	for _, ref := range refs {
+		if !IsGroupRef(ref) {
+			if _, err := c.GetRepoByAlias(ref); err != nil {
+				return fmt.Errorf("repository alias '%s' not found", ref)
+			}
+			if !seen[ref] {
+				seen[ref] = true
+				*aliases = append(*aliases, ref)
+			}
+			continue
+		}
+
+		name := strings.TrimPrefix(ref, GroupPrefix)
+		members, ok := c.Groups[name]
+		if !ok {
+			return fmt.Errorf("group '%s' not found", name)
+		}
+		for _, parent := range groups {
+			if parent == name {
+				return fmt.Errorf("group '%s' %w", name, errGroupCycle)
+			}
+		}
+		if err := c.expandRepos(members, append(groups, name), seen, aliases); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetContextAliases returns the aliases of the repositories of a context with
+// its groups expanded. References that cannot be resolved are left out.
+func (c *Config) GetContextAliases(contextName string) []string {
+	var aliases []string
+	seen := make(map[string]bool)
+	for _, ref := range c.Contexts[contextName] {
+		expanded, err := c.ExpandRepos([]string{ref})
+		if err != nil {
+			continue
+		}
+		for _, alias := range expanded {
+			if !seen[alias] {
+				seen[alias] = true
+				aliases = append(aliases, alias)
+			}
+		}
+	}
+	return aliases
+}
+
+// removeFromGroups removes a repository from every group. Groups left empty
+// are removed along with their references in other groups and contexts.
+func (c *Config) removeFromGroups(alias string) {
+	removed := []string{alias}
+	for len(removed) > 0 {
+		ref := removed[0]
+		removed = removed[1:]
+
+		for name, members := range c.Groups {
+			remaining := withoutRef(members, ref)
+			if len(remaining) == len(members) {
+				continue
+			}
+			if len(remaining) == 0 {
+				delete(c.Groups, name)
+				removed = append(removed, GroupPrefix+name)
+				continue
+			}
+			c.Groups[name] = remaining
+		}
+
+		if IsGroupRef(ref) {
+			for name, refs := range c.Contexts {
+				c.Contexts[name] = withoutRef(refs, ref)
+			}
+		}
+	}
+}
+
+func withoutRef(refs []string, ref string) []string {
+	var remaining []string
+	for _, existing := range refs {
+		if existing != ref {
+			remaining = append(remaining, existing)
+		}
+	}
+	return remaining
+}
@@ -0,0 +1,351 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ManifestFileName is the team manifest kept at the workspace root. Unlike
+// .alfred/, which is git-ignored, it is meant to be committed: it holds the
+// repositories, their remotes, the workspace settings and the shared contexts.
+// .alfred/alfred.yaml then only holds local state: personal contexts and any
+// setting overridden on this machine.
+const ManifestFileName = "alfred.yaml"
+
+func getManifestPath() string {
+	return filepath.Join(".", ManifestFileName)
+}
+
+// HasManifest reports whether the configuration was loaded from a team manifest
+func (c *Config) HasManifest() bool {
+	return c.manifest != nil
+}
+
+// IsSharedContext reports whether a context comes from the team manifest
+func (c *Config) IsSharedContext(name string) bool {
+	return c.sharedContexts[name]
+}
+
+// ShareContexts moves personal contexts into the team manifest, creating the
+// manifest from the current repositories and settings if there is none yet
+func (c *Config) ShareContexts(names []string) error {
+	for _, name := range names {
+		if !c.ContextExists(name) {
+			return fmt.Errorf("context '%s' does not exist", name)
+		}
+	}
+
+	if c.manifest == nil {
+		c.manifest = &Config{}
+	}
+	if c.sharedContexts == nil {
+		c.sharedContexts = make(map[string]bool)
+	}
+	for _, name := range names {
+		c.sharedContexts[name] = true
+	}
+	return nil
+}
+
+// readConfigFile parses a configuration file, returning nil if it does not exist
+func readConfigFile(path string) (*configFile, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	// Older formats are upgraded in memory, the file is only rewritten by
+	// alfred config migrate or the next save
+	if _, _, err := migrateNode(&root); err != nil {
+		return nil, fmt.Errorf("failed to load %s: %w", path, err)
+	}
+
+	var config Config
+	if len(root.Content) > 0 {
+		if err := root.Decode(&config); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+	}
+	return &configFile{path: path, config: &config, root: &root}, nil
+}
+
+// mergeConfigs overlays the local state on top of the team manifest. Settings
+// and repositories set locally win, personal contexts are added next to the
+// shared ones and replace a shared context with the same name unless they
+// list the same repositories.
+func mergeConfigs(manifest, local *Config) *Config {
+	merged := &Config{
+		Version:        manifest.Version,
+		Repos:          append([]Repository(nil), manifest.Repos...),
+		Master:         manifest.Master,
+		Mode:           manifest.Mode,
+		MainBranch:     manifest.MainBranch,
+		BranchTemplate: manifest.BranchTemplate,
+		CommitTemplate: manifest.CommitTemplate,
+		WorktreeDir:    manifest.WorktreeDir,
+		manifest:       manifest,
+		sharedContexts: make(map[string]bool),
+	}
+
+	for name, repos := range manifest.Contexts {
+		if merged.Contexts == nil {
+			merged.Contexts = make(map[string][]string)
+		}
+		merged.Contexts[name] = append([]string(nil), repos...)
+		merged.sharedContexts[name] = true
+		if info, ok := manifest.ContextInfos[name]; ok {
+			merged.SetContextInfo(name, info)
+		}
+	}
+	for name, members := range manifest.Groups {
+		if merged.Groups == nil {
+			merged.Groups = make(map[string][]string)
+		}
+		merged.Groups[name] = append([]string(nil), members...)
+	}
+	for name, branch := range manifest.ContextBranches {
+		if merged.ContextBranches == nil {
+			merged.ContextBranches = make(map[string]string)
+		}
+		merged.ContextBranches[name] = branch
+	}
+
+	if local == nil {
+		return merged
+	}
+
+	for _, setting := range []struct {
+		value  string
+		target *string
+	}{
+		{local.Master, &merged.Master},
+		{local.Mode, &merged.Mode},
+		{local.MainBranch, &merged.MainBranch},
+		{local.BranchTemplate, &merged.BranchTemplate},
+		{local.CommitTemplate, &merged.CommitTemplate},
+		{local.WorktreeDir, &merged.WorktreeDir},
+	} {
+		if setting.value != "" {
+			*setting.target = setting.value
+		}
+	}
+
+	for _, repo := range local.Repos {
+		if i := indexOfRepo(merged.Repos, repo.Identifier()); i >= 0 {
+			merged.Repos[i] = repo
+		} else {
+			merged.Repos = append(merged.Repos, repo)
+		}
+	}
+
+	for name, members := range local.Groups {
+		if merged.Groups == nil {
+			merged.Groups = make(map[string][]string)
+		}
+		merged.Groups[name] = members
+	}
+
+	for name, repos := range local.Contexts {
+		if merged.Contexts == nil {
+			merged.Contexts = make(map[string][]string)
+		}
+		// A local copy identical to the shared context, e.g. from before the
+		// manifest existed, does not override it
+		if shared, ok := manifest.Contexts[name]; ok && slices.Equal(shared, repos) {
+			continue
+		}
+		merged.Contexts[name] = repos
+		delete(merged.sharedContexts, name)
+		merged.SetContextInfo(name, local.ContextInfos[name])
+	}
+	// Shared contexts only take when they were last switched to from the
+	// local state
+	for name, info := range local.ContextInfos {
+		if merged.sharedContexts[name] && !info.LastSwitched.IsZero() {
+			shared := merged.GetContextInfo(name)
+			shared.LastSwitched = info.LastSwitched
+			merged.SetContextInfo(name, shared)
+		}
+	}
+	for name, branch := range local.ContextBranches {
+		if merged.ContextBranches == nil {
+			merged.ContextBranches = make(map[string]string)
+		}
+		merged.ContextBranches[name] = branch
+	}
+
+	return merged
+}
+
+// splitConfig divides the configuration into what belongs in the team manifest
+// and what is local state
+func (c *Config) splitConfig() (*Config, *Config) {
+	manifest := &Config{
+		Version:        c.Version,
+		Repos:          c.Repos,
+		Groups:         c.Groups,
+		Master:         c.Master,
+		Mode:           c.Mode,
+		MainBranch:     c.MainBranch,
+		BranchTemplate: c.BranchTemplate,
+		CommitTemplate: c.CommitTemplate,
+		WorktreeDir:    c.WorktreeDir,
+	}
+	local := &Config{Version: c.Version}
+
+	// A new manifest takes the current repositories and settings. Otherwise
+	// what is in the manifest stays there and local differences are overrides.
+	isNew := len(c.manifest.Repos) == 0
+	if !isNew {
+		manifest.Repos = c.manifest.Repos
+		for _, repo := range c.Repos {
+			if i := indexOfRepo(c.manifest.Repos, repo.Identifier()); i < 0 || c.manifest.Repos[i] != repo {
+				local.Repos = append(local.Repos, repo)
+			}
+		}
+
+		// Groups follow the repositories: the manifest keeps its definitions
+		// and local changes are overrides
+		manifest.Groups = nil
+		for name, members := range c.Groups {
+			shared, ok := c.manifest.Groups[name]
+			if ok {
+				if manifest.Groups == nil {
+					manifest.Groups = make(map[string][]string)
+				}
+				manifest.Groups[name] = shared
+			}
+			if !ok || !slices.Equal(shared, members) {
+				if local.Groups == nil {
+					local.Groups = make(map[string][]string)
+				}
+				local.Groups[name] = members
+			}
+		}
+
+		for _, setting := range []struct {
+			shared   string
+			current  string
+			fallback string
+			target   *string
+			local    *string
+		}{
+			{c.manifest.Master, c.Master, "", &manifest.Master, &local.Master},
+			{c.manifest.Mode, c.Mode, DefaultMode, &manifest.Mode, &local.Mode},
+			{c.manifest.MainBranch, c.MainBranch, "main", &manifest.MainBranch, &local.MainBranch},
+			{c.manifest.BranchTemplate, c.BranchTemplate, "", &manifest.BranchTemplate, &local.BranchTemplate},
+			{c.manifest.CommitTemplate, c.CommitTemplate, "", &manifest.CommitTemplate, &local.CommitTemplate},
+			{c.manifest.WorktreeDir, c.WorktreeDir, "", &manifest.WorktreeDir, &local.WorktreeDir},
+		} {
+			*setting.target = setting.shared
+
+			effective := setting.shared
+			if effective == "" {
+				effective = setting.fallback
+			}
+			if setting.current != effective {
+				*setting.local = setting.current
+			}
+		}
+	}
+
+	// Shared contexts overridden locally keep their manifest definition, the
+	// ones deleted or renamed are removed from it
+	for name, repos := range c.manifest.Contexts {
+		if !c.sharedContexts[name] && c.ContextExists(name) {
+			setContext(manifest, name, repos, c.manifest.ContextBranches[name], c.manifest.ContextInfos[name])
+		}
+	}
+	for name, repos := range c.Contexts {
+		info := c.GetContextInfo(name)
+		if !c.sharedContexts[name] {
+			setContext(local, name, repos, c.ContextBranches[name], info)
+			continue
+		}
+
+		// Switching is local state and must not rewrite the manifest
+		shared := info
+		shared.LastSwitched = time.Time{}
+		setContext(manifest, name, repos, c.ContextBranches[name], shared)
+		if !info.LastSwitched.IsZero() {
+			local.SetContextInfo(name, ContextInfo{LastSwitched: info.LastSwitched})
+		}
+	}
+
+	return manifest, local
+}
+
+func setContext(config *Config, name string, repos []string, branch string, info ContextInfo) {
+	if config.Contexts == nil {
+		config.Contexts = make(map[string][]string)
+	}
+	config.Contexts[name] = repos
+	if !info.isEmpty() || !info.LastSwitched.IsZero() {
+		config.SetContextInfo(name, info)
+	}
+
+	if branch != "" {
+		if config.ContextBranches == nil {
+			config.ContextBranches = make(map[string]string)
+		}
+		config.ContextBranches[name] = branch
+	}
+}
+
+// saveManifest writes the team manifest, leaving the file untouched when its
+// content did not change so comments and formatting are kept
+func saveManifest(manifest, previous *Config) error {
+	data, err := yaml.Marshal(manifest)
+	if err != nil {
+		return fmt.Errorf("failed to marshal manifest: %w", err)
+	}
+
+	if previous != nil {
+		if old, err := yaml.Marshal(previous); err == nil && bytes.Equal(old, data) {
+			if _, err := os.Stat(getManifestPath()); err == nil {
+				return nil
+			}
+		}
+	}
+
+	if err := writeFileAtomic(getManifestPath(), data); err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+	return nil
+}
+
+// SharedContextNames returns the sorted names of the contexts in the team manifest
+func (c *Config) SharedContextNames() []string {
+	var names []string
+	for name := range c.sharedContexts {
+		if c.ContextExists(name) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func indexOfRepo(repos []Repository, identifier string) int {
+	for i, repo := range repos {
+		if repo.Identifier() == identifier {
+			return i
+		}
+	}
+	return -1
+}
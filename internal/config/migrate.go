@@ -0,0 +1,297 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// CurrentVersion is the configuration format written by this version of
+// alfred. Files without a version are version 1. Raising it requires a
+// migration from the previous version and the new maximum in schema.json.
+const CurrentVersion = 2
+
+// migration upgrades a configuration file from one version to the next. It
+// works on the YAML node tree, so formats the Config struct can no longer
+// decode can still be read and comments are kept.
+type migration struct {
+	from        int
+	description string
+	apply       func(root *yaml.Node) error
+}
+
+// migrations holds one migration per version, in order
+var migrations = []migration{
+	{
+		// Version 2 only adds to version 1, but older alfred versions cannot
+		// read contexts written as mappings
+		from:        1,
+		description: "contexts can be written as mappings holding their metadata",
+		apply: func(root *yaml.Node) error {
+			return nil
+		},
+	},
+}
+
+// migrateNode upgrades a parsed configuration file to CurrentVersion and
+// records the version in it. It returns the version the file had and the
+// descriptions of the migrations applied.
+func migrateNode(doc *yaml.Node) (int, []string, error) {
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 {
+		return CurrentVersion, nil, nil
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return CurrentVersion, nil, nil
+	}
+
+	from := 1
+	versionNode := mappingValue(root, "version")
+	if versionNode != nil {
+		version, err := strconv.Atoi(versionNode.Value)
+		if err != nil || version < 1 {
+			return 0, nil, fmt.Errorf("invalid version '%s'", versionNode.Value)
+		}
+		from = version
+	}
+	if from > CurrentVersion {
+		return 0, nil, fmt.Errorf("configuration version %d is newer than the version %d supported by this alfred, please upgrade alfred", from, CurrentVersion)
+	}
+
+	var steps []string
+	for version := from; version < CurrentVersion; version++ {
+		step := findMigration(version)
+		if step == nil {
+			return 0, nil, fmt.Errorf("no migration from configuration version %d", version)
+		}
+		if err := step.apply(root); err != nil {
+			return 0, nil, fmt.Errorf("failed to migrate from version %d: %w", version, err)
+		}
+		steps = append(steps, step.description)
+	}
+
+	value := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: strconv.Itoa(CurrentVersion)}
+	if versionNode != nil {
+		*versionNode = *value
+	} else {
+		key := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "version"}
+		// Keep a comment at the top of the file above the new first key
+		if len(root.Content) > 0 {
+			key.HeadComment = root.Content[0].HeadComment
+			root.Content[0].HeadComment = ""
+		}
+		root.Content = append([]*yaml.Node{key, value}, root.Content...)
+	}
+	return from, steps, nil
+}
+
+func findMigration(from int) *migration {
+	for i := range migrations {
+		if migrations[i].from == from {
+			return &migrations[i]
+		}
+	}
+	return nil
+}
+
+func mappingValue(mapping *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// Migration is the upgrade of one configuration file to CurrentVersion
+type Migration struct {
+	File     string
+	From     int
+	Steps    []string
+	Original []byte
+	Migrated []byte
+	// Backup is where Apply copied the original file
+	Backup string
+}
+
+// PlanMigrations returns the migrations needed by the team manifest and the
+// local state, leaving the files untouched. Files that already record the
+// current version are skipped.
+func PlanMigrations() ([]*Migration, error) {
+	var planned []*Migration
+	for _, path := range []string{getManifestPath(), getConfigPath()} {
+		data, err := os.ReadFile(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+
+		var doc yaml.Node
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+		if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+			continue
+		}
+		if node := mappingValue(doc.Content[0], "version"); node != nil && node.Value == strconv.Itoa(CurrentVersion) {
+			continue
+		}
+
+		from, steps, err := migrateNode(&doc)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		if len(steps) == 0 {
+			steps = []string{fmt.Sprintf("record configuration version %d", CurrentVersion)}
+		}
+
+		var buf bytes.Buffer
+		encoder := yaml.NewEncoder(&buf)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(&doc); err != nil {
+			return nil, fmt.Errorf("failed to encode %s: %w", path, err)
+		}
+		if err := encoder.Close(); err != nil {
+			return nil, fmt.Errorf("failed to encode %s: %w", path, err)
+		}
+
+		planned = append(planned, &Migration{
+			File:     path,
+			From:     from,
+			Steps:    steps,
+			Original: data,
+			Migrated: buf.Bytes(),
+		})
+	}
+	return planned, nil
+}
+
+// Apply copies the original file to .alfred/ and writes the migrated one
+func (m *Migration) Apply() error {
+	if err := m.backup(); err != nil {
+		return err
+	}
+
+	if err := writeFileAtomic(m.File, m.Migrated); err != nil {
+		return fmt.Errorf("failed to write %s: %w", m.File, err)
+	}
+	return nil
+}
+
+// backup copies the original file to .alfred/, named after its version
+func (m *Migration) backup() error {
+	if err := ensureAlfredDir(); err != nil {
+		return err
+	}
+
+	name := strings.TrimSuffix(filepath.Base(m.File), filepath.Ext(m.File))
+	if m.File == getManifestPath() {
+		name += ".manifest"
+	}
+	m.Backup = filepath.Join(getAlfredDir(), fmt.Sprintf("%s.v%d.bak.yaml", name, m.From))
+	if err := os.WriteFile(m.Backup, m.Original, 0644); err != nil {
+		return fmt.Errorf("failed to back up %s: %w", m.File, err)
+	}
+	return nil
+}
+
+// Diff returns the changes made by the migration as a unified diff
+func (m *Migration) Diff() string {
+	return unifiedDiff(m.File, splitLines(m.Original), splitLines(m.Migrated))
+}
+
+func splitLines(data []byte) []string {
+	text := strings.TrimSuffix(string(data), "\n")
+	if text == "" {
+		return nil
+	}
+	return strings.Split(text, "\n")
+}
+
+// unifiedDiff compares two small files line by line, showing three lines of
+// context around each change
+func unifiedDiff(name string, a, b []string) string {
+	// Longest common subsequence table, from the end of both files
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	type line struct {
+		op   byte
+		text string
+		a, b int // line numbers before and after, 1-based
+	}
+	var lines []line
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			lines = append(lines, line{' ', a[i], i + 1, j + 1})
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			lines = append(lines, line{'-', a[i], i + 1, j + 1})
+			i++
+		default:
+			lines = append(lines, line{'+', b[j], i + 1, j + 1})
+			j++
+		}
+	}
+
+	const context = 3
+	var out strings.Builder
+	for start := 0; start < len(lines); {
+		if lines[start].op == ' ' {
+			start++
+			continue
+		}
+
+		// Extend the hunk while changes are close enough to share context
+		first := max(start-context, 0)
+		end := start
+		for k := start; k < len(lines); k++ {
+			if lines[k].op != ' ' {
+				end = k
+			} else if k-end > 2*context {
+				break
+			}
+		}
+		last := min(end+context, len(lines)-1)
+
+		if out.Len() == 0 {
+			fmt.Fprintf(&out, "--- %s\n+++ %s\n", name, name)
+		}
+		oldCount, newCount := 0, 0
+		for _, l := range lines[first : last+1] {
+			if l.op != '+' {
+				oldCount++
+			}
+			if l.op != '-' {
+				newCount++
+			}
+		}
+		fmt.Fprintf(&out, "@@ -%d,%d +%d,%d @@\n", lines[first].a, oldCount, lines[first].b, newCount)
+		for _, l := range lines[first : last+1] {
+			fmt.Fprintf(&out, "%c%s\n", l.op, l.text)
+		}
+		start = last + 1
+	}
+	return out.String()
+}
@@ -0,0 +1,204 @@
+package config
+
+import (
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// configFile is a parsed configuration file with its YAML node tree, kept to
+// report where invalid values are
+type configFile struct {
+	path   string
+	config *Config
+	root   *yaml.Node
+}
+
+// repoOrigin is the file and index a merged repository was read from
+type repoOrigin struct {
+	file  *configFile
+	index int
+}
+
+// locator maps problem paths of the configuration as loaded to positions in
+// its files
+type locator struct {
+	// files in lookup order: local state first, since it overrides the manifest
+	files []*configFile
+	repos []repoOrigin
+}
+
+func newLocator(manifest, local *configFile, merged *Config) *locator {
+	l := &locator{}
+	if local != nil {
+		l.files = append(l.files, local)
+	}
+	if manifest == nil {
+		for i := range local.config.Repos {
+			l.repos = append(l.repos, repoOrigin{local, i})
+		}
+		return l
+	}
+	l.files = append(l.files, manifest)
+
+	// Follow mergeConfigs: local repositories replace the manifest ones with
+	// the same identifier or are appended
+	for i := range manifest.config.Repos {
+		l.repos = append(l.repos, repoOrigin{manifest, i})
+	}
+	if local != nil {
+		for j, repo := range local.config.Repos {
+			if i := indexOfRepo(merged.Repos[:len(l.repos)], repo.Identifier()); i >= 0 {
+				l.repos[i] = repoOrigin{local, j}
+			} else {
+				l.repos = append(l.repos, repoOrigin{local, j})
+			}
+		}
+	}
+	return l
+}
+
+// Locate fills in the file, line and column of problems found in the
+// configuration as it was loaded. Problems that cannot be located, for
+// instance after the configuration was changed, are returned unchanged.
+func (c *Config) Locate(problems []Problem) []Problem {
+	if c.locator == nil {
+		return problems
+	}
+
+	located := make([]Problem, len(problems))
+	for i, problem := range problems {
+		located[i] = problem
+		if file, node := c.locator.find(problem.Path, len(c.Repos)); node != nil {
+			located[i].File = file.path
+			located[i].Line = node.Line
+			located[i].Column = node.Column
+		}
+	}
+	return located
+}
+
+func (l *locator) find(path string, repoCount int) (*configFile, *yaml.Node) {
+	segments := splitPath(path)
+	if len(segments) == 0 {
+		return nil, nil
+	}
+
+	if segments[0] == "repos" && len(segments) > 1 {
+		index, err := strconv.Atoi(segments[1])
+		if err != nil || index >= len(l.repos) || len(l.repos) != repoCount {
+			return nil, nil
+		}
+		origin := l.repos[index]
+		segments[1] = strconv.Itoa(origin.index)
+		node, _ := lookup(origin.file.root, segments)
+		if node == nil {
+			return nil, nil
+		}
+		return origin.file, node
+	}
+
+	// A value set in the local state wins, otherwise the deepest match is the
+	// closest position available
+	var bestFile *configFile
+	var bestNode *yaml.Node
+	bestDepth := 0
+	for _, file := range l.files {
+		node, depth := lookup(file.root, segments)
+		if depth == len(segments) {
+			return file, node
+		}
+		if depth > bestDepth {
+			bestFile, bestNode, bestDepth = file, node, depth
+		}
+	}
+	return bestFile, bestNode
+}
+
+// lookup walks segments down the node tree and returns the deepest node found
+// with the number of segments it matched. A key holding a mapping or a
+// sequence is reported at the key itself.
+func lookup(root *yaml.Node, segments []string) (*yaml.Node, int) {
+	if root == nil {
+		return nil, 0
+	}
+	node := root
+	if node.Kind == yaml.DocumentNode && len(node.Content) > 0 {
+		node = node.Content[0]
+	}
+
+	var found *yaml.Node
+	for depth, segment := range segments {
+		var next, at *yaml.Node
+		switch node.Kind {
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				if node.Content[i].Value == segment {
+					next = node.Content[i+1]
+					at = next
+					if next.Kind != yaml.ScalarNode {
+						at = node.Content[i]
+					}
+					break
+				}
+			}
+		case yaml.SequenceNode:
+			if index, err := strconv.Atoi(segment); err == nil && index >= 0 && index < len(node.Content) {
+				next = node.Content[index]
+				at = next
+			}
+		}
+		if next == nil {
+			return found, depth
+		}
+		node, found = next, at
+	}
+	return found, len(segments)
+}
+
+// splitPath splits a problem path such as "repos[1].alias" or
+// "contexts.release-1.2[0]" into its keys and indexes. Context and group
+// names may contain dots, so they are taken whole.
+func splitPath(path string) []string {
+	var segments []string
+	top, rest, _ := strings.Cut(path, ".")
+	top, index := splitIndex(top)
+	segments = append(segments, top)
+	if index != "" {
+		segments = append(segments, index)
+	}
+	if rest == "" {
+		return segments
+	}
+
+	if top == "contexts" || top == "context_branches" || top == "groups" {
+		key, index := splitIndex(rest)
+		segments = append(segments, key)
+		if index != "" {
+			segments = append(segments, index)
+		}
+		return segments
+	}
+
+	for _, part := range strings.Split(rest, ".") {
+		key, index := splitIndex(part)
+		segments = append(segments, key)
+		if index != "" {
+			segments = append(segments, index)
+		}
+	}
+	return segments
+}
+
+// splitIndex splits "name[2]" into "name" and "2"
+func splitIndex(segment string) (string, string) {
+	if !strings.HasSuffix(segment, "]") {
+		return segment, ""
+	}
+	open := strings.LastIndex(segment, "[")
+	if open < 0 {
+		return segment, ""
+	}
+	return segment[:open], segment[open+1 : len(segment)-1]
+}
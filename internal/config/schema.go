@@ -0,0 +1,9 @@
+package config
+
+import _ "embed"
+
+// Schema is the JSON Schema of alfred.yaml, for editors with YAML language
+// server support
+//
+//go:embed schema.json
+var Schema []byte
@@ -0,0 +1,187 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Problem is a configuration error found by Validate. Path locates the
+// offending value, e.g. "repos[1].alias" or "contexts.login[0]". File, Line
+// and Column are set by Locate when the value comes from a file. Warning
+// marks problems alfred can work around, which LoadConfig only reports.
+type Problem struct {
+	Path    string
+	Message string
+	File    string
+	Line    int
+	Column  int
+	Warning bool
+}
+
+func (p Problem) Error() string {
+	if p.Line > 0 {
+		return fmt.Sprintf("%s:%d:%d: %s: %s", p.File, p.Line, p.Column, p.Path, p.Message)
+	}
+	return fmt.Sprintf("%s: %s", p.Path, p.Message)
+}
+
+// ValidationError is returned by LoadConfig when the configuration is invalid
+type ValidationError struct {
+	Problems []Problem
+}
+
+func (e *ValidationError) Error() string {
+	var b strings.Builder
+	if len(e.Problems) == 1 {
+		b.WriteString("invalid configuration, 1 problem found:")
+	} else {
+		fmt.Fprintf(&b, "invalid configuration, %d problems found:", len(e.Problems))
+	}
+	for _, problem := range e.Problems {
+		b.WriteString("\n  " + problem.Error())
+	}
+	b.WriteString("\nRun 'alfred config edit' to fix them")
+	return b.String()
+}
+
+// Errors returns the problems that are not warnings
+func Errors(problems []Problem) []Problem {
+	var result []Problem
+	for _, problem := range problems {
+		if !problem.Warning {
+			result = append(result, problem)
+		}
+	}
+	return result
+}
+
+// Validate checks the consistency of the configuration and returns every
+// problem found
+func (c *Config) Validate() []Problem {
+	var problems []Problem
+	add := func(path, format string, args ...interface{}) {
+		problems = append(problems, Problem{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+	warn := func(path, format string, args ...interface{}) {
+		problems = append(problems, Problem{Path: path, Message: fmt.Sprintf(format, args...), Warning: true})
+	}
+
+	identifiers := make(map[string]int)
+	names := make(map[string]int)
+	for i, repo := range c.Repos {
+		path := fmt.Sprintf("repos[%d]", i)
+		if repo.Name == "" {
+			add(path+".name", "name is required")
+		}
+		if repo.Path == "" {
+			add(path+".path", "path is required")
+		}
+
+		firstName, nameTaken := names[repo.Name]
+		if !nameTaken && repo.Name != "" {
+			names[repo.Name] = i
+		}
+
+		identifier := repo.Identifier()
+		if identifier == "" {
+			continue
+		}
+		field := ".name"
+		if repo.Alias != "" {
+			field = ".alias"
+		}
+		if first, ok := identifiers[identifier]; ok {
+			add(path+field, "'%s' is already used by repos[%d]; set a different alias", identifier, first)
+			continue
+		}
+		identifiers[identifier] = i
+
+		// Dependencies are linked by package name, which is then ambiguous
+		if nameTaken {
+			warn(path+".name", "name '%s' is also used by repos[%d]; dependencies on it may link either repository", repo.Name, firstName)
+		}
+	}
+
+	if c.Mode != "" && c.Mode != ModeBranch && c.Mode != ModeWorktree {
+		add("mode", "invalid mode '%s'. Must be 'branch' or 'worktree'", c.Mode)
+	}
+
+	if c.Master != "" {
+		if _, ok := identifiers[c.Master]; !ok {
+			add("master", "repository '%s' is not in the repository list", c.Master)
+		}
+	}
+
+	// checkRef reports a context or group entry naming an unknown repository
+	// or group
+	checkRef := func(path, ref string) bool {
+		if IsGroupRef(ref) {
+			if _, ok := c.Groups[strings.TrimPrefix(ref, GroupPrefix)]; !ok {
+				add(path, "group '%s' is not defined", strings.TrimPrefix(ref, GroupPrefix))
+				return false
+			}
+			return true
+		}
+		if _, ok := identifiers[ref]; !ok {
+			add(path, "repository '%s' is not in the repository list", ref)
+			return false
+		}
+		return true
+	}
+
+	for _, name := range c.GetGroupNames() {
+		path := "groups." + name
+		if len(c.Groups[name]) == 0 {
+			add(path, "group has no repositories")
+		}
+		for i, ref := range c.Groups[name] {
+			checkRef(fmt.Sprintf("%s[%d]", path, i), ref)
+		}
+		if _, err := c.ExpandRepos([]string{GroupPrefix + name}); errors.Is(err, errGroupCycle) {
+			add(path, "%s", err)
+		}
+	}
+
+	var contextNames []string
+	for name := range c.Contexts {
+		contextNames = append(contextNames, name)
+	}
+	sort.Strings(contextNames)
+
+	for _, name := range contextNames {
+		path := "contexts." + name
+		if name == "main" || name == "master" {
+			add(path, "'%s' is reserved for the main branches", name)
+		}
+		if len(c.Contexts[name]) == 0 {
+			add(path, "context has no repositories")
+		}
+		if mode := c.ContextInfos[name].Mode; mode != "" && mode != ModeBranch && mode != ModeWorktree {
+			add(path, "invalid mode '%s'. Must be 'branch' or 'worktree'", mode)
+		}
+
+		seen := make(map[string]bool)
+		for i, ref := range c.Contexts[name] {
+			if checkRef(fmt.Sprintf("%s[%d]", path, i), ref) && seen[ref] {
+				warn(fmt.Sprintf("%s[%d]", path, i), "'%s' is listed twice", ref)
+			}
+			seen[ref] = true
+		}
+	}
+
+	var branchNames []string
+	for name := range c.ContextBranches {
+		branchNames = append(branchNames, name)
+	}
+	sort.Strings(branchNames)
+
+	for _, name := range branchNames {
+		if !c.ContextExists(name) {
+			warn("context_branches."+name, "context '%s' does not exist", name)
+		}
+	}
+
+	return problems
+}
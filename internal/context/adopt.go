@@ -0,0 +1,145 @@
+package context
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/viniciusamelio/alfred/internal/git"
+)
+
+// AdoptCandidate is a branch shared by several repositories that can be
+// registered as a context
+type AdoptCandidate struct {
+	Branch     string
+	Name       string   // proposed context name
+	Repos      []string // repositories that have the branch, locally or on their remote
+	RemoteOnly []string // repositories where the branch only exists on the remote
+}
+
+// FindAdoptCandidates scans the local and remote branches of every configured
+// repository and groups branches with the same name found in at least two of
+// them. Main branches and branches already used by a context are skipped.
+func (m *Manager) FindAdoptCandidates(fetch bool) ([]*AdoptCandidate, error) {
+	known := make(map[string]bool)
+	for contextName := range m.config.Contexts {
+		known[m.config.GetBranchName(contextName)] = true
+	}
+	for i := range m.config.Repos {
+		known[m.config.GetRepoMainBranch(&m.config.Repos[i])] = true
+	}
+
+	candidates := make(map[string]*AdoptCandidate)
+	for i := range m.config.Repos {
+		repo := &m.config.Repos[i]
+		repoIdentifier := repo.Identifier()
+
+		gitRepo := git.NewGitRepo(repo.Path)
+		if !gitRepo.IsGitRepo() {
+			continue
+		}
+
+		if fetch {
+			if err := gitRepo.Fetch(repo.GetRemote()); err != nil {
+				m.logger.Warnf("Failed to fetch %s: %v", repoIdentifier, err)
+			}
+		}
+
+		local, err := gitRepo.ListLocalBranches()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", repoIdentifier, err)
+		}
+		remote, err := gitRepo.ListRemoteBranches(repo.GetRemote())
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", repoIdentifier, err)
+		}
+
+		branches := make(map[string]bool) // branch -> exists locally
+		for _, branch := range remote {
+			branches[branch] = false
+		}
+		for _, branch := range local {
+			branches[branch] = true
+		}
+
+		for branch, isLocal := range branches {
+			if known[branch] {
+				continue
+			}
+
+			candidate, ok := candidates[branch]
+			if !ok {
+				candidate = &AdoptCandidate{Branch: branch}
+				candidates[branch] = candidate
+			}
+			candidate.Repos = append(candidate.Repos, repoIdentifier)
+			if !isLocal {
+				candidate.RemoteOnly = append(candidate.RemoteOnly, repoIdentifier)
+			}
+		}
+	}
+
+	var result []*AdoptCandidate
+	for _, candidate := range candidates {
+		if len(candidate.Repos) < 2 {
+			continue
+		}
+		candidate.Name = m.adoptContextName(candidate.Branch)
+		result = append(result, candidate)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Branch < result[j].Branch
+	})
+	return result, nil
+}
+
+// adoptContextName proposes a context name for a branch: its last path segment,
+// or the whole branch with slashes replaced when that name is taken
+func (m *Manager) adoptContextName(branch string) string {
+	name := branch[strings.LastIndex(branch, "/")+1:]
+	if name == "" || name == "main" || name == "master" || m.config.ContextExists(name) {
+		name = strings.ReplaceAll(branch, "/", "-")
+	}
+	return name
+}
+
+// AdoptContext registers a candidate as a context under contextName, creating
+// local tracking branches where the branch only exists on the remote. The
+// branch is recorded in context_branches when the branch template would not
+// produce it.
+func (m *Manager) AdoptContext(candidate *AdoptCandidate, contextName string) error {
+	if contextName == "" || contextName == "main" || contextName == "master" || m.config.ContextExists(contextName) {
+		return fmt.Errorf("context '%s' already exists", contextName)
+	}
+
+	for _, alias := range candidate.RemoteOnly {
+		repo, err := m.config.GetRepoByAlias(alias)
+		if err != nil {
+			return err
+		}
+
+		if err := git.NewGitRepo(repo.Path).TrackRemoteBranch(candidate.Branch, repo.GetRemote()); err != nil {
+			return fmt.Errorf("%s: %w", alias, err)
+		}
+		m.logger.Infof("Created branch %s tracking %s/%s in %s", candidate.Branch, repo.GetRemote(), candidate.Branch, alias)
+	}
+
+	if err := m.config.AddContext(contextName, candidate.Repos); err != nil {
+		return err
+	}
+
+	if m.config.GetBranchName(contextName) != candidate.Branch {
+		if m.config.ContextBranches == nil {
+			m.config.ContextBranches = make(map[string]string)
+		}
+		m.config.ContextBranches[contextName] = candidate.Branch
+	}
+
+	if err := m.config.Save(); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+
+	m.logger.Infof("Adopted branch %s as context %s", candidate.Branch, contextName)
+	return nil
+}
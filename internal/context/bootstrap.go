@@ -0,0 +1,144 @@
+package context
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/viniciusamelio/alfred/internal/config"
+	"github.com/viniciusamelio/alfred/internal/git"
+	"github.com/viniciusamelio/alfred/internal/pubspec"
+)
+
+const (
+	BootstrapCloned  = "cloned"
+	BootstrapPresent = "present"
+	BootstrapSkipped = "skipped"
+	BootstrapFailed  = "failed"
+
+	DefaultBootstrapJobs = 4
+)
+
+// BootstrapResult reports what happened to a repository during a bootstrap
+type BootstrapResult struct {
+	Name   string
+	Path   string
+	Branch string
+	Status string
+	Err    error
+}
+
+// Bootstrap clones every configured repository missing from disk from its url,
+// up to jobs at a time, checks out its main branch and verifies that the
+// pubspec name matches the configured name. Repositories already present are
+// only verified. progress is called as each repository finishes; results are
+// returned in configuration order.
+func (m *Manager) Bootstrap(jobs int, progress func(BootstrapResult)) []BootstrapResult {
+	if jobs < 1 {
+		jobs = DefaultBootstrapJobs
+	}
+
+	results := make([]BootstrapResult, len(m.config.Repos))
+	semaphore := make(chan struct{}, jobs)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+
+	for i := range m.config.Repos {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			result := m.bootstrapRepo(&m.config.Repos[i])
+			results[i] = result
+
+			if progress != nil {
+				mu.Lock()
+				progress(result)
+				mu.Unlock()
+			}
+		}(i)
+	}
+
+	wg.Wait()
+	return results
+}
+
+func (m *Manager) bootstrapRepo(repo *config.Repository) BootstrapResult {
+	repoIdentifier := repo.Identifier()
+
+	result := BootstrapResult{
+		Name:   repoIdentifier,
+		Path:   repo.Path,
+		Branch: m.config.GetRepoMainBranch(repo),
+	}
+	fail := func(err error) BootstrapResult {
+		result.Status = BootstrapFailed
+		result.Err = err
+		return result
+	}
+
+	if _, err := os.Stat(repo.Path); err == nil {
+		if !git.NewGitRepo(repo.Path).IsGitRepo() {
+			return fail(fmt.Errorf("%s exists but is not a git repository", repo.Path))
+		}
+		result.Status = BootstrapPresent
+	} else if repo.URL == "" {
+		result.Status = BootstrapSkipped
+		result.Err = fmt.Errorf("no url configured")
+		return result
+	} else {
+		if err := os.MkdirAll(filepath.Dir(repo.Path), 0755); err != nil {
+			return fail(fmt.Errorf("failed to create parent directory: %w", err))
+		}
+
+		gitRepo, err := git.Clone(repo.URL, repo.Path, repo.GetRemote())
+		if err != nil {
+			return fail(err)
+		}
+
+		if err := m.checkoutMainBranch(gitRepo, repo, result.Branch); err != nil {
+			return fail(err)
+		}
+		result.Status = BootstrapCloned
+	}
+
+	pubspecFile, err := pubspec.LoadPubspec(repo.Path)
+	if err != nil {
+		return fail(err)
+	}
+	packageName, err := pubspecFile.GetPackageName()
+	if err != nil {
+		return fail(err)
+	}
+	if packageName != repo.Name {
+		return fail(fmt.Errorf("pubspec name is '%s' but the repository is configured as '%s'", packageName, repo.Name))
+	}
+
+	return result
+}
+
+// checkoutMainBranch checks out the main branch of a fresh clone, whose default
+// branch may differ from the configured one
+func (m *Manager) checkoutMainBranch(gitRepo *git.GitRepo, repo *config.Repository, mainBranch string) error {
+	if currentBranch, err := gitRepo.GetCurrentBranch(); err == nil && currentBranch == mainBranch {
+		return nil
+	}
+
+	exists, err := gitRepo.BranchExists(mainBranch)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		if err := gitRepo.TrackRemoteBranch(mainBranch, repo.GetRemote()); err != nil {
+			return err
+		}
+	}
+
+	if err := gitRepo.CheckoutBranch(mainBranch); err != nil {
+		return fmt.Errorf("failed to checkout %s: %w", mainBranch, err)
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package context
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/viniciusamelio/alfred/internal/config"
+	"github.com/viniciusamelio/alfred/internal/git"
+	"github.com/viniciusamelio/alfred/internal/testutil"
+)
+
+// createRemote creates a bare repository whose main branch holds a pubspec
+// named packageName, plus a develop branch, and returns its file:// url
+func createRemote(t *testing.T, root, name, packageName string) string {
+	t.Helper()
+
+	remote := testutil.CreateRemote(t, root, name, "main", map[string]string{"pubspec.yaml": fmt.Sprintf("name: %s\n", packageName)})
+	testutil.RunGit(t, remote, "branch", "develop", "main")
+
+	return "file://" + remote
+}
+
+func TestManager_Bootstrap(t *testing.T) {
+	testutil.SetGitIdentity(t)
+
+	root := t.TempDir()
+	workspace := filepath.Join(root, "workspace")
+
+	cfg := &config.Config{
+		MainBranch: "main",
+		Repos: []config.Repository{
+			{Name: "app", Path: filepath.Join(workspace, "app"), URL: createRemote(t, root, "app", "app")},
+			{Name: "core", Path: filepath.Join(workspace, "packages", "core"), URL: createRemote(t, root, "core", "core"), MainBranch: "develop", Remote: "upstream"},
+			{Name: "ui", Path: filepath.Join(workspace, "ui"), URL: createRemote(t, root, "ui", "design_system")},
+			{Name: "legacy", Path: filepath.Join(workspace, "legacy")},
+		},
+	}
+
+	manager := NewManager(cfg)
+	var reported int
+	results := manager.Bootstrap(2, func(BootstrapResult) { reported++ })
+
+	if reported != len(cfg.Repos) {
+		t.Errorf("Expected progress for %d repositories, got %d", len(cfg.Repos), reported)
+	}
+
+	expected := []string{BootstrapCloned, BootstrapCloned, BootstrapFailed, BootstrapSkipped}
+	for i, result := range results {
+		if result.Status != expected[i] {
+			t.Errorf("%s: expected status %s, got %s (%v)", result.Name, expected[i], result.Status, result.Err)
+		}
+	}
+
+	core := git.NewGitRepo(cfg.Repos[1].Path)
+	if branch, err := core.GetCurrentBranch(); err != nil || branch != "develop" {
+		t.Errorf("Expected core to be on its main branch develop, got %q (%v)", branch, err)
+	}
+	if tracking := core.GetBranchTracking("develop"); tracking.Remote != "upstream" {
+		t.Errorf("Expected develop to track upstream, got %+v", tracking)
+	}
+
+	// Running again only verifies what is already there
+	results = manager.Bootstrap(2, nil)
+	if results[0].Status != BootstrapPresent || results[1].Status != BootstrapPresent {
+		t.Errorf("Expected cloned repositories to be reported as present, got %s and %s", results[0].Status, results[1].Status)
+	}
+}
@@ -0,0 +1,56 @@
+package context
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/viniciusamelio/alfred/internal/config"
+	"github.com/viniciusamelio/alfred/internal/git"
+	"github.com/viniciusamelio/alfred/internal/pubspec"
+)
+
+// CheckWorkspace verifies that every configured repository is a git repository
+// at its path whose pubspec name matches the configured name. Problems use the
+// same paths as config.Validate.
+func (m *Manager) CheckWorkspace() []config.Problem {
+	var problems []config.Problem
+	for i, repo := range m.config.Repos {
+		if repo.Path == "" {
+			continue
+		}
+		path := fmt.Sprintf("repos[%d].path", i)
+
+		info, err := os.Stat(repo.Path)
+		if os.IsNotExist(err) {
+			message := fmt.Sprintf("%s does not exist", repo.Path)
+			if repo.URL != "" {
+				message += "; run 'alfred bootstrap' to clone it"
+			}
+			problems = append(problems, config.Problem{Path: path, Message: message})
+			continue
+		}
+		if err != nil || !info.IsDir() {
+			problems = append(problems, config.Problem{Path: path, Message: fmt.Sprintf("%s is not a directory", repo.Path)})
+			continue
+		}
+		if !git.NewGitRepo(repo.Path).IsGitRepo() {
+			problems = append(problems, config.Problem{Path: path, Message: fmt.Sprintf("%s is not a git repository", repo.Path)})
+		}
+
+		pubspecFile, err := pubspec.LoadPubspec(repo.Path)
+		if err != nil {
+			problems = append(problems, config.Problem{Path: path, Message: fmt.Sprintf("no pubspec.yaml in %s", repo.Path)})
+			continue
+		}
+		packageName, err := pubspecFile.GetPackageName()
+		if err != nil {
+			problems = append(problems, config.Problem{Path: path, Message: fmt.Sprintf("%s/pubspec.yaml has no name", repo.Path)})
+		} else if packageName != repo.Name {
+			problems = append(problems, config.Problem{
+				Path:    fmt.Sprintf("repos[%d].name", i),
+				Message: fmt.Sprintf("pubspec name is '%s' but the repository is configured as '%s'", packageName, repo.Name),
+			})
+		}
+	}
+	return problems
+}
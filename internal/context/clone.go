@@ -0,0 +1,151 @@
+package context
+
+import (
+	"fmt"
+
+	"github.com/viniciusamelio/alfred/internal/git"
+	"github.com/viniciusamelio/alfred/internal/worktree"
+)
+
+// CloneOptions controls how a context is cloned
+type CloneOptions struct {
+	// CopyChanges carries the uncommitted changes of the source context over
+	CopyChanges bool
+}
+
+// CloneContext creates dstName with the repositories of srcName, branching every
+// repository from the tip of the source branch. Worktrees are created and linked
+// right away. With CopyChanges, uncommitted changes of the source checkouts are
+// applied to the new worktrees, or stashed for repositories switched in place so
+// they are restored when switching to the new context. Every step is undone if
+// a later one fails.
+func (m *Manager) CloneContext(srcName, dstName string, opts CloneOptions) error {
+	if !m.config.ContextExists(srcName) {
+		return fmt.Errorf("context '%s' not found", srcName)
+	}
+	if m.config.ContextExists(dstName) || dstName == "main" || dstName == "master" {
+		return fmt.Errorf("context '%s' already exists", dstName)
+	}
+
+	repos, err := m.config.GetContextRepos(srcName)
+	if err != nil {
+		return err
+	}
+
+	if err := m.config.AddContext(dstName, append([]string(nil), m.config.Contexts[srcName]...)); err != nil {
+		return err
+	}
+
+	// Undo steps run in reverse order when something fails
+	var undo []func() error
+	rollback := func(cause error) error {
+		m.logger.Warnf("Clone failed, rolling back: %v", cause)
+		for i := len(undo) - 1; i >= 0; i-- {
+			if err := undo[i](); err != nil {
+				m.logger.Warnf("Failed to roll back: %v", err)
+			}
+		}
+		return cause
+	}
+
+	saved := false
+	undo = append(undo, func() error {
+		if err := m.config.RemoveContext(dstName); err != nil {
+			return err
+		}
+		if saved {
+			return m.config.Save()
+		}
+		return nil
+	})
+
+	srcBranch := m.config.GetBranchName(srcName)
+	info := m.config.GetContextInfo(dstName)
+	info.Base = srcBranch
+	info.Mode = m.config.GetContextInfo(srcName).Mode
+	m.config.SetContextInfo(dstName, info)
+	dstBranch := m.config.GetBranchName(dstName)
+
+	// Check every repository before creating anything
+	for _, repo := range repos {
+		gitRepo := git.NewGitRepo(repo.Path)
+		if !gitRepo.IsGitRepo() {
+			return rollback(fmt.Errorf("repository %s is not a git repository", repo.Name))
+		}
+		if exists, err := gitRepo.BranchExists(dstBranch); err != nil {
+			return rollback(err)
+		} else if exists {
+			return rollback(fmt.Errorf("branch %s already exists in %s", dstBranch, repo.Name))
+		}
+	}
+
+	m.logger.Infof("Cloning context %s to %s (branch %s -> %s)", srcName, dstName, srcBranch, dstBranch)
+
+	var worktrees []*worktree.WorktreeInfo
+	for _, repo := range repos {
+		repoIdentifier := repo.Identifier()
+
+		gitRepo := git.NewGitRepo(repo.Path)
+
+		// Repositories the source context was never switched to start from main
+		startPoint := srcBranch
+		if exists, err := gitRepo.BranchExists(srcBranch); err != nil || !exists {
+			startPoint = m.getMainRef(repo)
+			m.logger.Infof("Branch %s does not exist in %s, starting from %s", srcBranch, repoIdentifier, startPoint)
+		}
+
+		if err := gitRepo.CreateBranchAt(dstBranch, startPoint); err != nil {
+			return rollback(fmt.Errorf("%s: %w", repoIdentifier, err))
+		}
+		undo = append(undo, func() error { return m.deleteBranchIfExists(repo, dstBranch) })
+		m.logger.Infof("Created branch %s from %s in %s", dstBranch, startPoint, repoIdentifier)
+
+		var changes string
+		if opts.CopyChanges {
+			currentBranch, _ := gitRepo.GetCurrentBranch()
+			if srcPath, _ := m.getBranchCheckout(repo, srcName, srcBranch, currentBranch); srcPath != "" && git.NewGitRepo(srcPath).IsGitRepo() {
+				changes, err = git.NewGitRepo(srcPath).CreateStashCommit()
+				if err != nil {
+					return rollback(fmt.Errorf("%s: %w", repoIdentifier, err))
+				}
+			}
+		}
+
+		if _, usesWorktree := m.getBranchCheckout(repo, dstName, dstBranch, ""); usesWorktree {
+			worktreeInfo, err := m.worktreeManager.CreateWorktreeForContext(repo, dstName)
+			if err != nil {
+				return rollback(fmt.Errorf("failed to create worktree for %s: %w", repoIdentifier, err))
+			}
+			undo = append(undo, func() error { return m.worktreeManager.RemoveWorktreeForContext(repo, dstName) })
+			worktrees = append(worktrees, worktreeInfo)
+
+			if changes != "" {
+				if err := git.NewGitRepo(worktreeInfo.WorktreePath).ApplyStash(changes); err != nil {
+					return rollback(fmt.Errorf("%s: %w", repoIdentifier, err))
+				}
+				m.logger.Infof("Copied uncommitted changes of %s into %s", repoIdentifier, worktreeInfo.WorktreePath)
+			}
+		} else if changes != "" {
+			if err := gitRepo.StoreStash(changes, fmt.Sprintf("alfred-context-%s", dstName)); err != nil {
+				return rollback(fmt.Errorf("%s: %w", repoIdentifier, err))
+			}
+			undo = append(undo, func() error { return gitRepo.DropStash(changes) })
+			m.logger.Infof("Stashed uncommitted changes of %s for context %s", repoIdentifier, dstName)
+		}
+	}
+
+	if err := m.config.Save(); err != nil {
+		return rollback(fmt.Errorf("failed to save config: %w", err))
+	}
+	saved = true
+
+	// Repositories switched in place are linked when switching to the context
+	if len(worktrees) > 0 {
+		if err := m.updatePubspecFilesForWorktrees(worktrees, dstName); err != nil {
+			return rollback(fmt.Errorf("failed to update pubspec files: %w", err))
+		}
+	}
+
+	m.logger.Infof("Cloned context %s to %s", srcName, dstName)
+	return nil
+}
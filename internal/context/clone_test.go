@@ -0,0 +1,54 @@
+package context
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/viniciusamelio/alfred/internal/config"
+	"github.com/viniciusamelio/alfred/internal/git"
+	"github.com/viniciusamelio/alfred/internal/testutil"
+)
+
+func TestManager_CloneContextRollsBack(t *testing.T) {
+	testutil.SetGitIdentity(t)
+
+	workspace := t.TempDir()
+	testutil.Chdir(t, workspace)
+
+	var repos []config.Repository
+	for _, name := range []string{"app", "ui", "core"} {
+		path := filepath.Join(workspace, name)
+		testutil.InitRepo(t, path, "main", nil)
+		repos = append(repos, config.Repository{Name: name, Path: path})
+	}
+
+	cfg := &config.Config{
+		Repos:      repos,
+		Master:     "app",
+		Mode:       config.ModeWorktree,
+		MainBranch: "main",
+		Contexts:   map[string][]string{"feat": {"app", "ui", "core"}},
+	}
+	m := NewManager(cfg)
+
+	// The worktree of core cannot be created, after app and ui were branched
+	coreWorktree := m.worktreeManager.GetWorktreePath(&cfg.Repos[2], "copy")
+	testutil.WriteFile(t, filepath.Dir(coreWorktree), filepath.Base(coreWorktree), "in the way\n")
+
+	if err := m.CloneContext("feat", "copy", CloneOptions{}); err == nil {
+		t.Fatal("Expected the clone to fail")
+	}
+
+	if cfg.ContextExists("copy") {
+		t.Error("Expected the context to be removed from the config")
+	}
+	for _, repo := range repos {
+		if exists, err := git.NewGitRepo(repo.Path).BranchExists("copy"); err != nil || exists {
+			t.Errorf("Expected the branch of copy to be deleted in %s, got %v (%v)", repo.Name, exists, err)
+		}
+	}
+	if _, err := os.Stat(m.worktreeManager.GetWorktreePath(&cfg.Repos[1], "copy")); !os.IsNotExist(err) {
+		t.Errorf("Expected the worktree of ui to be removed, got %v", err)
+	}
+}
@@ -5,12 +5,15 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/log"
 	"github.com/viniciusamelio/alfred/internal/config"
 	"github.com/viniciusamelio/alfred/internal/git"
 	"github.com/viniciusamelio/alfred/internal/pubspec"
+	"github.com/viniciusamelio/alfred/internal/status"
 	"github.com/viniciusamelio/alfred/internal/tui"
 	"github.com/viniciusamelio/alfred/internal/worktree"
 )
@@ -59,7 +62,8 @@ func (m *Manager) SetCurrentContext(contextName string) error {
 }
 
 func (m *Manager) SwitchContext(contextName string) error {
-	m.logger.Infof("Switching to context: %s (mode: %s)", contextName, m.config.Mode)
+	mode := m.config.GetContextMode(contextName)
+	m.logger.Infof("Switching to context: %s (mode: %s)", contextName, mode)
 
 	currentContext, err := m.GetCurrentContext()
 	if err != nil {
@@ -71,11 +75,30 @@ func (m *Manager) SwitchContext(contextName string) error {
 		return nil
 	}
 
-	if m.config.IsBranchMode() {
-		return m.switchContextBranchMode(contextName, currentContext)
+	if err := m.leaveContext(currentContext, contextName); err != nil {
+		return fmt.Errorf("failed to leave context '%s': %w", currentContext, err)
+	}
+
+	if mode == config.ModeBranch {
+		err = m.switchContextBranchMode(contextName, currentContext)
 	} else {
-		return m.switchContextWorktreeMode(contextName, currentContext)
+		err = m.switchContextWorktreeMode(contextName, currentContext)
+	}
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if err := m.recordSwitch(currentContext, contextName, now); err != nil {
+		m.logger.Warnf("Failed to record the switch in the history: %v", err)
 	}
+	if m.config.ContextExists(contextName) {
+		m.config.MarkSwitched(contextName, now)
+		if err := m.config.Save(); err != nil {
+			m.logger.Warnf("Failed to record the switch to '%s': %v", contextName, err)
+		}
+	}
+	return nil
 }
 
 func (m *Manager) switchContextBranchMode(contextName string, currentContext string) error {
@@ -85,8 +108,9 @@ func (m *Manager) switchContextBranchMode(contextName string, currentContext str
 		return err
 	}
 
-	// Step 1: Stash changes in all repos (branch mode uses git stash)
-	if currentContext != "" {
+	// Step 1: Stash changes in all repos (branch mode uses git stash). A
+	// worktree-mode context was stashed by leaveContext.
+	if currentContext != "" && m.config.IsContextBranchMode(currentContext) {
 		if err := m.stashAllRepos(repos, currentContext); err != nil {
 			m.logger.Warnf("Failed to stash changes in repos: %v", err)
 		}
@@ -95,6 +119,11 @@ func (m *Manager) switchContextBranchMode(contextName string, currentContext str
 	// Step 2: Switch all repos to context branch
 	var repoInfos []*worktree.WorktreeInfo
 	for _, repo := range repos {
+		if contextName != "main" && contextName != "master" {
+			if err := m.releaseWorktree(repo, contextName); err != nil {
+				return fmt.Errorf("failed to release the worktree of %s: %w", repo.Alias, err)
+			}
+		}
 		if err := m.switchRepoToContext(repo, contextName); err != nil {
 			return fmt.Errorf("failed to switch repo %s to context: %w", repo.Alias, err)
 		}
@@ -103,7 +132,7 @@ func (m *Manager) switchContextBranchMode(contextName string, currentContext str
 		repoInfo := &worktree.WorktreeInfo{
 			Repo:         repo,
 			WorktreePath: repo.Path,
-			BranchName:   contextName,
+			BranchName:   m.config.GetBranchName(contextName),
 		}
 		repoInfos = append(repoInfos, repoInfo)
 	}
@@ -156,12 +185,13 @@ func (m *Manager) switchContextWorktreeMode(contextName string, currentContext s
 		masterWorktreeInfo = &worktree.WorktreeInfo{
 			Repo:         masterRepo,
 			WorktreePath: masterRepo.Path,
-			BranchName:   contextName,
+			BranchName:   m.config.GetBranchName(contextName),
 		}
 	}
 
-	// Step 2: Stash changes in current context worktrees (excluding master)
-	if currentContext != "" {
+	// Step 2: Stash changes in current context worktrees (excluding master). A
+	// branch-mode context was stashed by leaveContext.
+	if currentContext != "" && !m.config.IsContextBranchMode(currentContext) {
 		if err := m.stashCurrentContextWorktrees(currentContext); err != nil {
 			m.logger.Warnf("Failed to stash current context: %v", err)
 		}
@@ -179,6 +209,9 @@ func (m *Manager) switchContextWorktreeMode(contextName string, currentContext s
 	}
 
 	for _, repo := range nonMasterRepos {
+		if err := m.releaseOriginalPath(repo, contextName); err != nil {
+			return fmt.Errorf("failed to release the branch of %s: %w", repo.Alias, err)
+		}
 		worktreeInfo, err := m.worktreeManager.CreateWorktreeForContext(repo, contextName)
 		if err != nil {
 			return fmt.Errorf("failed to create worktree for repo %s: %w", repo.Alias, err)
@@ -217,31 +250,29 @@ func (m *Manager) switchContextWorktreeMode(contextName string, currentContext s
 func (m *Manager) switchMasterRepoToContext(masterRepo *config.Repository, contextName string) error {
 	gitRepo := git.NewGitRepo(masterRepo.Path)
 
-	repoIdentifier := masterRepo.Alias
-	if repoIdentifier == "" {
-		repoIdentifier = masterRepo.Name
-	}
+	repoIdentifier := masterRepo.Identifier()
 
 	if !gitRepo.IsGitRepo() {
 		return fmt.Errorf("master repository %s is not a git repository", repoIdentifier)
 	}
 
 	// Check if branch exists
-	branchExists, err := gitRepo.BranchExists(contextName)
+	branchName := m.config.GetBranchName(contextName)
+	branchExists, err := gitRepo.BranchExists(branchName)
 	if err != nil {
 		return fmt.Errorf("failed to check if branch exists: %w", err)
 	}
 
 	if !branchExists {
 		// Create new branch from current branch
-		m.logger.Infof("Creating new branch %s in master repo %s", contextName, repoIdentifier)
-		if err := gitRepo.CreateBranch(contextName, "HEAD"); err != nil {
+		m.logger.Infof("Creating new branch %s in master repo %s", branchName, repoIdentifier)
+		if err := gitRepo.CreateBranch(branchName, "HEAD"); err != nil {
 			return fmt.Errorf("failed to create branch: %w", err)
 		}
 	} else {
 		// Switch to existing branch
-		m.logger.Infof("Switching to existing branch %s in master repo %s", contextName, repoIdentifier)
-		if err := gitRepo.CheckoutBranch(contextName); err != nil {
+		m.logger.Infof("Switching to existing branch %s in master repo %s", branchName, repoIdentifier)
+		if err := gitRepo.CheckoutBranch(branchName); err != nil {
 			return fmt.Errorf("failed to checkout branch: %w", err)
 		}
 	}
@@ -267,21 +298,22 @@ func (m *Manager) switchRepoToContext(repo *config.Repository, contextName strin
 	}
 
 	// Check if branch exists
-	branchExists, err := gitRepo.BranchExists(contextName)
+	branchName := m.config.GetBranchName(contextName)
+	branchExists, err := gitRepo.BranchExists(branchName)
 	if err != nil {
 		return fmt.Errorf("failed to check if branch exists: %w", err)
 	}
 
 	if !branchExists {
 		// Create new branch from current branch
-		m.logger.Infof("Creating new branch %s in repo %s", contextName, repo.Alias)
-		if err := gitRepo.CreateBranch(contextName, "HEAD"); err != nil {
+		m.logger.Infof("Creating new branch %s in repo %s", branchName, repo.Alias)
+		if err := gitRepo.CreateBranch(branchName, "HEAD"); err != nil {
 			return fmt.Errorf("failed to create branch: %w", err)
 		}
 	} else {
 		// Switch to existing branch
-		m.logger.Infof("Switching to existing branch %s in repo %s", contextName, repo.Alias)
-		if err := gitRepo.CheckoutBranch(contextName); err != nil {
+		m.logger.Infof("Switching to existing branch %s in repo %s", branchName, repo.Alias)
+		if err := gitRepo.CheckoutBranch(branchName); err != nil {
 			return fmt.Errorf("failed to checkout branch: %w", err)
 		}
 	}
@@ -290,19 +322,37 @@ func (m *Manager) switchRepoToContext(repo *config.Repository, contextName strin
 }
 
 func (m *Manager) switchRepoToMainBranch(gitRepo *git.GitRepo, repo *config.Repository) error {
-	// Get the configured main branch name
-	configuredMainBranch := m.config.GetMainBranch()
+	repoIdentifier := repo.Identifier()
+
+	// Get the main branch name for this repository (per-repo override or global)
+	configuredMainBranch := m.config.GetRepoMainBranch(repo)
 
 	// First, try the configured main branch
 	branchExists, err := gitRepo.BranchExists(configuredMainBranch)
 	if err == nil && branchExists {
-		m.logger.Infof("Switching repo %s to configured main branch: %s", repo.Alias, configuredMainBranch)
+		m.logger.Infof("Switching repo %s to configured main branch: %s", repoIdentifier, configuredMainBranch)
 		if err := gitRepo.CheckoutBranch(configuredMainBranch); err != nil {
 			return fmt.Errorf("failed to checkout main branch %s: %w", configuredMainBranch, err)
 		}
 		return nil
 	}
 
+	// The branch may only exist on the remote (e.g. a fresh clone of another branch)
+	remote := repo.GetRemote()
+	if remoteExists, err := gitRepo.RemoteBranchExists(remote, configuredMainBranch); err == nil && remoteExists {
+		m.logger.Infof("Creating %s in repo %s tracking %s/%s", configuredMainBranch, repoIdentifier, remote, configuredMainBranch)
+		if err := gitRepo.CreateTrackingBranch(configuredMainBranch, remote); err != nil {
+			return fmt.Errorf("failed to check out main branch %s from %s: %w", configuredMainBranch, remote, err)
+		}
+		return nil
+	}
+
+	// An explicit per-repository main branch must exist, don't guess
+	if repo.MainBranch != "" {
+		return fmt.Errorf("main branch '%s' configured for repo %s does not exist locally or on '%s'",
+			configuredMainBranch, repoIdentifier, remote)
+	}
+
 	// If configured main branch doesn't exist, try common alternatives
 	mainBranchCandidates := []string{"main", "master", "develop"}
 
@@ -321,7 +371,8 @@ func (m *Manager) switchRepoToMainBranch(gitRepo *git.GitRepo, repo *config.Repo
 		}
 
 		if branchExists {
-			m.logger.Infof("Configured main branch '%s' not found in repo %s, switching to: %s", configuredMainBranch, repo.Alias, branchName)
+			m.logger.Warnf("Configured main branch '%s' not found in repo %s, switching to: %s (set main_branch for this repository to silence this)",
+				configuredMainBranch, repoIdentifier, branchName)
 			if err := gitRepo.CheckoutBranch(branchName); err != nil {
 				return fmt.Errorf("failed to checkout main branch %s: %w", branchName, err)
 			}
@@ -329,14 +380,8 @@ func (m *Manager) switchRepoToMainBranch(gitRepo *git.GitRepo, repo *config.Repo
 		}
 	}
 
-	// If no standard main branch found, try to get the default branch
-	currentBranch, err := gitRepo.GetCurrentBranch()
-	if err != nil {
-		return fmt.Errorf("failed to get current branch for repo %s: %w", repo.Alias, err)
-	}
-
-	m.logger.Infof("No main branch candidates found in repo %s (including configured '%s'), staying on current branch: %s", repo.Alias, configuredMainBranch, currentBranch)
-	return nil
+	return fmt.Errorf("no main branch found in repo %s (tried '%s' and %s); set main_branch for this repository in alfred.yaml",
+		repoIdentifier, configuredMainBranch, strings.Join(filteredCandidates, ", "))
 }
 
 func (m *Manager) switchToMainContext(currentContext string) error {
@@ -448,16 +493,10 @@ func (m *Manager) updatePubspecFilesForBranchMode(repoInfos []*worktree.Worktree
 		// Update dependencies to point to other repos in the same context
 		for _, otherRepo := range repoInfos {
 			// Get the correct identifier for current repo
-			currentRepoIdentifier := repoInfo.Repo.Alias
-			if currentRepoIdentifier == "" {
-				currentRepoIdentifier = repoInfo.Repo.Name
-			}
+			currentRepoIdentifier := repoInfo.Repo.Identifier()
 
 			// Get the correct identifier for other repo
-			otherRepoIdentifier := otherRepo.Repo.Alias
-			if otherRepoIdentifier == "" {
-				otherRepoIdentifier = otherRepo.Repo.Name
-			}
+			otherRepoIdentifier := otherRepo.Repo.Identifier()
 
 			if otherRepoIdentifier == currentRepoIdentifier {
 				continue
@@ -473,9 +512,16 @@ func (m *Manager) updatePubspecFilesForBranchMode(repoInfos []*worktree.Worktree
 			// Use the package name (from pubspec.yaml) for dependency identification
 			dependencyName := otherRepo.Repo.Name
 
+			// A path dependency may still point at the worktree layout, e.g. when
+			// the context used worktrees before
 			if err := pubspecFile.CommentGitDependencyAndAddPath(dependencyName, relativePath); err != nil {
-				m.logger.Debugf("Dependency %s not found or not a git dependency in %s: %v",
-					dependencyName, currentRepoIdentifier, err)
+				if err2 := pubspecFile.UpdatePathDependency(dependencyName, relativePath); err2 != nil {
+					m.logger.Debugf("Dependency %s not found as git or path dependency in %s: git_error=%v, path_error=%v",
+						dependencyName, currentRepoIdentifier, err, err2)
+				} else {
+					m.logger.Infof("Updated %s path dependency in %s to: %s",
+						dependencyName, currentRepoIdentifier, relativePath)
+				}
 			} else {
 				m.logger.Infof("Commented git and added path dependency for %s in %s",
 					dependencyName, currentRepoIdentifier)
@@ -541,16 +587,10 @@ func (m *Manager) updatePubspecFilesForWorktrees(worktrees []*worktree.WorktreeI
 		// Only convert dependencies to repos that are also in this context
 		for _, otherWorktree := range worktrees {
 			// Get the correct identifier for current repo
-			currentRepoIdentifier := worktreeInfo.Repo.Alias
-			if currentRepoIdentifier == "" {
-				currentRepoIdentifier = worktreeInfo.Repo.Name
-			}
+			currentRepoIdentifier := worktreeInfo.Repo.Identifier()
 
 			// Get the correct identifier for other repo
-			otherRepoIdentifier := otherWorktree.Repo.Alias
-			if otherRepoIdentifier == "" {
-				otherRepoIdentifier = otherWorktree.Repo.Name
-			}
+			otherRepoIdentifier := otherWorktree.Repo.Identifier()
 
 			if otherRepoIdentifier == currentRepoIdentifier {
 				continue
@@ -608,11 +648,58 @@ func (m *Manager) updatePubspecFilesForWorktrees(worktrees []*worktree.WorktreeI
 	return nil
 }
 
+// getContextWorktreeInfos returns the checkout location of every repository in a context
+func (m *Manager) getContextWorktreeInfos(contextName string) ([]*worktree.WorktreeInfo, error) {
+	repos, err := m.config.GetContextRepos(contextName)
+	if err != nil {
+		return nil, err
+	}
+
+	var infos []*worktree.WorktreeInfo
+	for _, repo := range repos {
+		infos = append(infos, &worktree.WorktreeInfo{
+			Repo:         repo,
+			WorktreePath: m.worktreeManager.GetRepoPath(repo, contextName),
+			BranchName:   m.config.GetBranchName(contextName),
+		})
+	}
+	return infos, nil
+}
+
+// RelinkContext rewrites pubspec path dependencies between the repositories of a
+// context and runs flutter pub get, e.g. after worktrees were moved or updated
+func (m *Manager) RelinkContext(contextName string) error {
+	if contextName == "" || contextName == "main" || contextName == "master" {
+		return nil
+	}
+
+	infos, err := m.getContextWorktreeInfos(contextName)
+	if err != nil {
+		return err
+	}
+
+	if m.config.IsContextBranchMode(contextName) {
+		err = m.updatePubspecFilesForBranchMode(infos, contextName)
+	} else {
+		err = m.updatePubspecFilesForWorktrees(infos, contextName)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update pubspec files: %w", err)
+	}
+
+	if err := m.runFlutterPubGet(infos); err != nil {
+		m.logger.Warnf("Failed to run flutter pub get: %v", err)
+	}
+	return nil
+}
+
 func (m *Manager) ListContexts() []string {
 	return m.config.GetContextNames()
 }
 
-func (m *Manager) GetContextStatus() (string, map[string]string, error) {
+// GetContextStatus collects the status of every repository in the current context,
+// sorted by repository name
+func (m *Manager) GetContextStatus(opts status.Options) (string, []*status.RepoStatus, error) {
 	currentContext, err := m.GetCurrentContext()
 	if err != nil {
 		return "", nil, err
@@ -627,30 +714,109 @@ func (m *Manager) GetContextStatus() (string, map[string]string, error) {
 		return currentContext, nil, err
 	}
 
-	// Get worktrees for the current context
-	worktrees, err := m.worktreeManager.ListWorktreesForContext(repos, currentContext)
+	var statuses []*status.RepoStatus
+	for _, repo := range repos {
+		repoIdentifier := repo.Identifier()
+
+		var siblings []*config.Repository
+		for _, other := range repos {
+			if other != repo {
+				siblings = append(siblings, other)
+			}
+		}
+
+		repoPath := m.worktreeManager.GetRepoPath(repo, currentContext)
+		statuses = append(statuses, status.CollectRepoStatus(repo, repoPath, siblings, repoIdentifier == m.config.Master, opts))
+	}
+
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Name < statuses[j].Name
+	})
+
+	return currentContext, statuses, nil
+}
+
+// ContextBranchStatus holds the branch status of one context in every configured repository
+type ContextBranchStatus struct {
+	Name    string
+	Current bool
+	Repos   map[string]*status.BranchStatus // keyed by repository alias or name
+}
+
+// GetAllContextsStatus inspects the branches of every context across all repositories
+func (m *Manager) GetAllContextsStatus() ([]*ContextBranchStatus, error) {
+	currentContext, err := m.GetCurrentContext()
 	if err != nil {
-		return currentContext, nil, fmt.Errorf("failed to list worktrees: %w", err)
+		return nil, err
 	}
 
-	status := make(map[string]string)
-	for _, worktreeInfo := range worktrees {
-		worktreeStatus, err := m.worktreeManager.GetWorktreeStatus(worktreeInfo)
-		if err != nil {
-			status[worktreeInfo.Repo.Alias] = fmt.Sprintf("Error: %v", err)
-		} else {
-			status[worktreeInfo.Repo.Alias] = worktreeStatus
+	// Current branch of each original checkout, to find branches checked out in place
+	checkedOut := make(map[string]string)
+	for i := range m.config.Repos {
+		repo := &m.config.Repos[i]
+		if branch, err := git.NewGitRepo(repo.Path).GetCurrentBranch(); err == nil {
+			checkedOut[repo.Path] = branch
 		}
 	}
 
-	// Check for repos that don't have worktrees yet
-	for _, repo := range repos {
-		if _, exists := status[repo.Alias]; !exists {
-			status[repo.Alias] = "No worktree (not switched to this context yet)"
+	var contextNames []string
+	for name := range m.config.Contexts {
+		contextNames = append(contextNames, name)
+	}
+	sort.Strings(contextNames)
+
+	var result []*ContextBranchStatus
+	for _, contextName := range contextNames {
+		repos, err := m.config.GetContextRepos(contextName)
+		if err != nil {
+			return nil, err
 		}
+
+		row := &ContextBranchStatus{
+			Name:    contextName,
+			Current: contextName == currentContext,
+			Repos:   make(map[string]*status.BranchStatus),
+		}
+
+		branchName := m.config.GetBranchName(contextName)
+		for _, repo := range repos {
+			repoIdentifier := repo.Identifier()
+
+			checkoutPath, usesWorktree := m.getBranchCheckout(repo, contextName, branchName, checkedOut[repo.Path])
+			row.Repos[repoIdentifier] = status.CollectBranchStatus(repo, branchName, m.getMainRef(repo), checkoutPath, usesWorktree)
+		}
+
+		result = append(result, row)
 	}
 
-	return currentContext, status, nil
+	return result, nil
+}
+
+// getBranchCheckout returns where the context branch of repo is checked out,
+// or an empty path if it is not, and whether the repository uses a worktree for
+// the context. currentBranch is the branch checked out in the repository itself.
+func (m *Manager) getBranchCheckout(repo *config.Repository, contextName, branchName, currentBranch string) (string, bool) {
+	repoIdentifier := repo.Identifier()
+
+	usesWorktree := !m.config.IsContextBranchMode(contextName) && repoIdentifier != m.config.Master
+	if usesWorktree {
+		return m.worktreeManager.GetWorktreePath(repo, contextName), true
+	}
+	if currentBranch == branchName {
+		return repo.Path, false
+	}
+	return "", false
+}
+
+// getMainRef returns the ref context branches are compared against, preferring
+// the remote-tracking main branch over the local one
+func (m *Manager) getMainRef(repo *config.Repository) string {
+	mainBranch := m.config.GetRepoMainBranch(repo)
+	remoteRef := fmt.Sprintf("%s/%s", repo.GetRemote(), mainBranch)
+	if _, err := git.NewGitRepo(repo.Path).ResolveRef(remoteRef); err == nil {
+		return remoteRef
+	}
+	return mainBranch
 }
 
 func (m *Manager) runFlutterPubGet(worktrees []*worktree.WorktreeInfo) error {
@@ -682,10 +848,15 @@ func (m *Manager) runFlutterPubGet(worktrees []*worktree.WorktreeInfo) error {
 	return nil
 }
 
-func (m *Manager) DeleteContexts(contextNames []string) error {
+func (m *Manager) DeleteContexts(contextNames []string, opts DeleteOptions) error {
 	m.logger.Infof("Deleting contexts: %s", strings.Join(contextNames, ", "))
 
 	for _, contextName := range contextNames {
+		if opts.Backup {
+			if err := m.backupContext(contextName); err != nil {
+				return fmt.Errorf("failed to back up context %s: %w", contextName, err)
+			}
+		}
 		if err := m.deleteContext(contextName); err != nil {
 			return fmt.Errorf("failed to delete context %s: %w", contextName, err)
 		}
@@ -725,9 +896,10 @@ func (m *Manager) deleteContext(contextName string) error {
 		return err
 	}
 
+	branchName := m.config.GetBranchName(contextName)
 	for _, repo := range allRepos {
-		if err := m.deleteBranchIfExists(repo, contextName); err != nil {
-			m.logger.Warnf("Failed to delete branch %s in %s: %v", contextName, repo.Alias, err)
+		if err := m.deleteBranchIfExists(repo, branchName); err != nil {
+			m.logger.Warnf("Failed to delete branch %s in %s: %v", branchName, repo.Alias, err)
 		}
 	}
 
@@ -786,10 +958,7 @@ func (m *Manager) handleMasterRepoStashForMainSwitch(currentContext string) erro
 	}
 
 	// Show confirmation dialog via TUI
-	repoIdentifier := masterRepo.Alias
-	if repoIdentifier == "" {
-		repoIdentifier = masterRepo.Name
-	}
+	repoIdentifier := masterRepo.Identifier()
 
 	// Try TUI confirmation, if it fails (no TTY), auto-confirm
 	confirmed, err := tui.RunStashConfirmation(currentContext, repoIdentifier)
@@ -844,10 +1013,7 @@ func (m *Manager) handleMasterRepoStashRestore(targetContext string) error {
 			return nil
 		}
 
-		repoIdentifier := masterRepo.Alias
-		if repoIdentifier == "" {
-			repoIdentifier = masterRepo.Name
-		}
+		repoIdentifier := masterRepo.Identifier()
 
 		m.logger.Infof("Restored stashed changes in master repo %s from context %s", repoIdentifier, targetContext)
 	}
@@ -902,10 +1068,7 @@ func (m *Manager) runFlutterPubGetForRepo(repo *config.Repository) error {
 		return nil
 	}
 
-	repoIdentifier := repo.Alias
-	if repoIdentifier == "" {
-		repoIdentifier = repo.Name
-	}
+	repoIdentifier := repo.Identifier()
 
 	m.logger.Infof("Running flutter pub get in %s (path: %s)", repoIdentifier, repo.Path)
 	cmd := exec.Command("flutter", "pub", "get")
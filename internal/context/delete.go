@@ -0,0 +1,200 @@
+package context
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/viniciusamelio/alfred/internal/config"
+	"github.com/viniciusamelio/alfred/internal/git"
+	"github.com/viniciusamelio/alfred/internal/status"
+)
+
+// TrashRefPrefix is where branch tips are backed up before a context is deleted
+const TrashRefPrefix = "refs/alfred/trash/"
+
+// DeleteOptions controls how contexts are deleted
+type DeleteOptions struct {
+	// Backup keeps the branch tips and uncommitted changes under refs/alfred/trash/
+	Backup bool
+}
+
+// RepoDeleteRisk describes what deleting a context would discard in one repository
+type RepoDeleteRisk struct {
+	Name         string
+	CheckoutPath string
+	BranchExists bool
+	Dirty        bool
+	Unpushed     int
+	Unmerged     int
+	Stashes      int
+}
+
+// HasRisk reports whether deleting the context would lose work in this repository.
+// Commits that are not merged but exist on a remote are not considered lost.
+func (r *RepoDeleteRisk) HasRisk() bool {
+	return r.Dirty || r.Unpushed > 0 || r.Stashes > 0
+}
+
+// Describe lists what would be lost, e.g. "2 unpushed commits"
+func (r *RepoDeleteRisk) Describe() []string {
+	var items []string
+	if r.Dirty {
+		items = append(items, "uncommitted changes")
+	}
+	if r.Unpushed > 0 {
+		items = append(items, fmt.Sprintf("%d unpushed commits", r.Unpushed))
+	}
+	if r.Unmerged > 0 && r.Unpushed < r.Unmerged {
+		items = append(items, fmt.Sprintf("%d commits not merged into main", r.Unmerged))
+	}
+	if r.Stashes > 0 {
+		items = append(items, fmt.Sprintf("%d alfred stashes", r.Stashes))
+	}
+	return items
+}
+
+// DeleteRisk describes what deleting a context would discard
+type DeleteRisk struct {
+	Context string
+	Repos   []*RepoDeleteRisk
+}
+
+// HasRisk reports whether deleting the context would lose work in any repository
+func (d *DeleteRisk) HasRisk() bool {
+	for _, repo := range d.Repos {
+		if repo.HasRisk() {
+			return true
+		}
+	}
+	return false
+}
+
+// Lines renders one line per repository with something worth mentioning
+func (d *DeleteRisk) Lines() []string {
+	var lines []string
+	for _, repo := range d.Repos {
+		if items := repo.Describe(); len(items) > 0 {
+			lines = append(lines, fmt.Sprintf("%s: %s", repo.Name, strings.Join(items, ", ")))
+		}
+	}
+	return lines
+}
+
+// CheckDeleteSafety inspects every repository of a context for work that would
+// be lost by deleting it: uncommitted changes in its checkout, commits that
+// are neither pushed nor merged, and stashes alfred created for the context
+func (m *Manager) CheckDeleteSafety(contextName string) (*DeleteRisk, error) {
+	repos, err := m.config.GetContextRepos(contextName)
+	if err != nil {
+		return nil, err
+	}
+
+	risk := &DeleteRisk{Context: contextName}
+	branchName := m.config.GetBranchName(contextName)
+
+	for _, repo := range repos {
+		repoIdentifier := repo.Identifier()
+
+		repoRisk := &RepoDeleteRisk{Name: repoIdentifier}
+		risk.Repos = append(risk.Repos, repoRisk)
+
+		gitRepo := git.NewGitRepo(repo.Path)
+		if !gitRepo.IsGitRepo() {
+			continue
+		}
+
+		if stashes, err := gitRepo.CountStashesForContext(contextName); err == nil {
+			repoRisk.Stashes = stashes
+		}
+
+		if exists, err := gitRepo.BranchExists(branchName); err != nil || !exists {
+			continue
+		}
+		repoRisk.BranchExists = true
+
+		currentBranch, _ := gitRepo.GetCurrentBranch()
+		checkoutPath, _ := m.getBranchCheckout(repo, contextName, branchName, currentBranch)
+		if checkoutPath != "" {
+			checkout := git.NewGitRepo(checkoutPath)
+			if checkout.IsGitRepo() {
+				repoRisk.CheckoutPath = checkoutPath
+				if files, err := checkout.GetChangedFiles(); err == nil {
+					repoRisk.Dirty = status.HasUserChanges(checkout, files)
+				}
+			}
+		}
+
+		if unpushed, err := gitRepo.CountUnpushedCommits(branchName); err == nil {
+			repoRisk.Unpushed = unpushed
+		}
+
+		if unmerged, _, err := gitRepo.GetAheadBehind(branchName, m.getMainRef(repo)); err == nil {
+			repoRisk.Unmerged = unmerged
+		}
+	}
+
+	return risk, nil
+}
+
+// backupContext stores the branch tip of every repository of a context, and the
+// uncommitted changes of its checkouts, under refs/alfred/trash/<timestamp>/
+func (m *Manager) backupContext(contextName string) error {
+	risk, err := m.CheckDeleteSafety(contextName)
+	if err != nil {
+		return err
+	}
+
+	repos, err := m.config.GetContextRepos(contextName)
+	if err != nil {
+		return err
+	}
+
+	refBase := m.trashRef(contextName)
+	for i, repo := range repos {
+		if err := m.backupRepo(repo, risk.Repos[i], m.config.GetBranchName(contextName), refBase); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// trashRef returns the ref a context branch is backed up to
+func (m *Manager) trashRef(contextName string) string {
+	return fmt.Sprintf("%s%d/%s", TrashRefPrefix, time.Now().Unix(), m.config.GetBranchName(contextName))
+}
+
+// backupRepo stores the tip of branchName at refBase, and the uncommitted
+// changes of its checkout at refBase-wip
+func (m *Manager) backupRepo(repo *config.Repository, repoRisk *RepoDeleteRisk, branchName, refBase string) error {
+	if !repoRisk.BranchExists {
+		return nil
+	}
+
+	gitRepo := git.NewGitRepo(repo.Path)
+	tip, err := gitRepo.ResolveRef(branchName)
+	if err != nil {
+		return fmt.Errorf("%s: %w", repoRisk.Name, err)
+	}
+	if err := gitRepo.UpdateRef(refBase, tip); err != nil {
+		return fmt.Errorf("%s: %w", repoRisk.Name, err)
+	}
+	m.logger.Infof("Backed up %s in %s to %s", branchName, repoRisk.Name, refBase)
+
+	if !repoRisk.Dirty {
+		return nil
+	}
+
+	wip, err := git.NewGitRepo(repoRisk.CheckoutPath).CreateStashCommitWithUntracked()
+	if err != nil {
+		return fmt.Errorf("%s: %w", repoRisk.Name, err)
+	}
+	if wip == "" {
+		return nil
+	}
+	if err := gitRepo.UpdateRef(refBase+"-wip", wip); err != nil {
+		return fmt.Errorf("%s: %w", repoRisk.Name, err)
+	}
+	m.logger.Infof("Backed up uncommitted changes in %s to %s-wip", repoRisk.Name, refBase)
+	return nil
+}
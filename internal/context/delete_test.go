@@ -0,0 +1,129 @@
+package context
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/viniciusamelio/alfred/internal/config"
+	"github.com/viniciusamelio/alfred/internal/git"
+	"github.com/viniciusamelio/alfred/internal/testutil"
+)
+
+func TestManager_CheckDeleteSafetyPubspec(t *testing.T) {
+	testutil.SetGitIdentity(t)
+
+	workspace := t.TempDir()
+	testutil.Chdir(t, workspace)
+
+	app := filepath.Join(workspace, "app")
+	appPubspec := "name: app\ndependencies:\n  core:\n    git:\n      url: git@example.com:core.git\n      ref: main\n"
+	testutil.InitRepo(t, app, "main", map[string]string{"pubspec.yaml": appPubspec})
+	testutil.InitRepo(t, filepath.Join(workspace, "core"), "main", map[string]string{"pubspec.yaml": "name: core\n"})
+
+	cfg := &config.Config{
+		Repos: []config.Repository{
+			{Name: "app", Path: app},
+			{Name: "core", Path: filepath.Join(workspace, "core")},
+		},
+		Master:     "app",
+		Mode:       config.ModeBranch,
+		MainBranch: "main",
+		Contexts:   map[string][]string{"feat": {"app", "core"}},
+	}
+	m := NewManager(cfg)
+
+	if err := m.SwitchContext("feat"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Repositories without a remote count as unpushed, so only the changes of
+	// the checkout are checked: the path dependency alfred linked is not work
+	// of the user
+	risk, err := m.CheckDeleteSafety("feat")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if risk.Repos[0].Dirty {
+		t.Fatalf("Expected the linked pubspec not to count as a change, got %v", risk.Lines())
+	}
+
+	data, err := os.ReadFile(filepath.Join(app, "pubspec.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	content := string(data)
+	if !strings.Contains(content, "path: ../core") {
+		t.Fatalf("Expected core to be linked, got:\n%s", content)
+	}
+
+	// A dependency added by hand is
+	testutil.WriteFile(t, app, "pubspec.yaml", content+"  http: ^1.0.0\n")
+	risk, err = m.CheckDeleteSafety("feat")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !risk.HasRisk() || !risk.Repos[0].Dirty {
+		t.Errorf("Expected the edited pubspec to block the deletion, got %v", risk.Lines())
+	}
+}
+
+func TestManager_BackupContextKeepsUntrackedFiles(t *testing.T) {
+	testutil.SetGitIdentity(t)
+
+	workspace := t.TempDir()
+	testutil.Chdir(t, workspace)
+
+	for _, name := range []string{"app", "core"} {
+		testutil.InitRepo(t, filepath.Join(workspace, name), "main", nil)
+	}
+	cfg := &config.Config{
+		Repos: []config.Repository{
+			{Name: "app", Path: filepath.Join(workspace, "app")},
+			{Name: "core", Path: filepath.Join(workspace, "core")},
+		},
+		Master:     "app",
+		Mode:       config.ModeWorktree,
+		MainBranch: "main",
+		Contexts:   map[string][]string{"feat": {"app", "core"}},
+	}
+	m := NewManager(cfg)
+	if err := m.SwitchContext("feat"); err != nil {
+		t.Fatal(err)
+	}
+
+	// The only change of core is a new file
+	worktree := filepath.Join(workspace, "core-feat")
+	testutil.WriteFile(t, worktree, "draft.txt", "draft\n")
+
+	if err := m.backupContext("feat"); err != nil {
+		t.Fatal(err)
+	}
+
+	output, err := exec.Command("git", "-C", worktree, "for-each-ref", "--format=%(refname)", TrashRefPrefix).Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var wip string
+	for _, ref := range strings.Fields(string(output)) {
+		if strings.HasSuffix(ref, "-wip") {
+			wip = ref
+		}
+	}
+	if wip == "" {
+		t.Fatalf("Expected the untracked file to be backed up, got refs %q", output)
+	}
+	if draft, err := exec.Command("git", "-C", worktree, "show", wip+"^3:draft.txt").Output(); err != nil || string(draft) != "draft\n" {
+		t.Errorf("Expected draft.txt in %s, got %q (%v)", wip, draft, err)
+	}
+
+	// The worktree and the stash list are left as they were
+	if _, err := os.Stat(filepath.Join(worktree, "draft.txt")); err != nil {
+		t.Errorf("Expected draft.txt to stay in the worktree, got %v", err)
+	}
+	if stashes, err := git.NewGitRepo(worktree).ListStashes(); err != nil || len(stashes) != 0 {
+		t.Errorf("Expected no stash left, got %v (%v)", stashes, err)
+	}
+}
@@ -0,0 +1,91 @@
+package context
+
+import (
+	"fmt"
+
+	"github.com/viniciusamelio/alfred/internal/git"
+)
+
+// FetchContext checks out a context that exists on the remotes, e.g. one pushed
+// by a teammate. Every repository is fetched and those with the context branch
+// on their remote are given a local tracking branch. The context is registered
+// with those repositories, or they are added to it if it already exists, and
+// it is switched to, which creates the worktrees, links pubspecs and runs pub get.
+func (m *Manager) FetchContext(contextName string) ([]string, error) {
+	if contextName == "" || contextName == "main" || contextName == "master" {
+		return nil, fmt.Errorf("invalid context name '%s'", contextName)
+	}
+
+	branchName := m.config.GetBranchName(contextName)
+	var found []string
+
+	for i := range m.config.Repos {
+		repo := &m.config.Repos[i]
+		repoIdentifier := repo.Identifier()
+
+		gitRepo := git.NewGitRepo(repo.Path)
+		if !gitRepo.IsGitRepo() {
+			m.logger.Warnf("Skipping %s: not a git repository", repoIdentifier)
+			continue
+		}
+
+		remote := repo.GetRemote()
+		m.logger.Infof("Fetching %s in %s", remote, repoIdentifier)
+		if err := gitRepo.Fetch(remote); err != nil {
+			return nil, fmt.Errorf("%s: %w", repoIdentifier, err)
+		}
+
+		remoteRef := fmt.Sprintf("%s/%s", remote, branchName)
+		if _, err := gitRepo.ResolveRef(remoteRef); err != nil {
+			continue
+		}
+		found = append(found, repoIdentifier)
+
+		exists, err := gitRepo.BranchExists(branchName)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", repoIdentifier, err)
+		}
+		if exists {
+			m.logger.Infof("Branch %s already exists in %s, keeping it", branchName, repoIdentifier)
+			continue
+		}
+
+		if err := gitRepo.TrackRemoteBranch(branchName, remote); err != nil {
+			return nil, fmt.Errorf("%s: %w", repoIdentifier, err)
+		}
+		m.logger.Infof("Created branch %s tracking %s in %s", branchName, remoteRef, repoIdentifier)
+	}
+
+	if len(found) == 0 {
+		return nil, fmt.Errorf("branch %s was not found on the remote of any repository", branchName)
+	}
+
+	if m.config.ContextExists(contextName) {
+		for _, alias := range found {
+			if m.config.ContextContainsRepo(contextName, alias) {
+				continue
+			}
+			if err := m.AddRepoToContext(contextName, alias); err != nil {
+				return nil, fmt.Errorf("failed to add %s: %w", alias, err)
+			}
+		}
+	} else {
+		if err := m.config.AddContext(contextName, found); err != nil {
+			return nil, err
+		}
+		if err := m.config.Save(); err != nil {
+			return nil, fmt.Errorf("failed to save config: %w", err)
+		}
+	}
+
+	currentContext, err := m.GetCurrentContext()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current context: %w", err)
+	}
+	if currentContext == contextName {
+		// Repositories added to the active context were already linked
+		return found, nil
+	}
+
+	return found, m.SwitchContext(contextName)
+}
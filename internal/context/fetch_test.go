@@ -0,0 +1,71 @@
+package context
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/viniciusamelio/alfred/internal/config"
+	"github.com/viniciusamelio/alfred/internal/git"
+	"github.com/viniciusamelio/alfred/internal/testutil"
+)
+
+func TestManager_FetchContext(t *testing.T) {
+	testutil.SetGitIdentity(t)
+
+	root := t.TempDir()
+	workspace := filepath.Join(root, "workspace")
+	testutil.Chdir(t, root)
+
+	// A teammate pushed login to the remotes of app and core, not ui
+	var repos []config.Repository
+	for _, name := range []string{"app", "core", "ui"} {
+		remote := testutil.CreateRemote(t, root, name, "main", nil)
+		if name != "ui" {
+			seed := filepath.Join(root, "seed", name)
+			testutil.RunGit(t, seed, "checkout", "-q", "-b", "login")
+			testutil.RunGit(t, seed, "commit", "-q", "--allow-empty", "-m", "login")
+			testutil.RunGit(t, seed, "push", "-q", remote, "login")
+		}
+
+		path := filepath.Join(workspace, name)
+		testutil.RunGit(t, root, "clone", "-q", remote, path)
+		repos = append(repos, config.Repository{Name: name, Path: path})
+	}
+
+	cfg := &config.Config{
+		Repos:      repos,
+		Master:     "app",
+		Mode:       config.ModeBranch,
+		MainBranch: "main",
+		Contexts:   map[string][]string{},
+	}
+	m := NewManager(cfg)
+
+	found, err := m.FetchContext("login")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(found, []string{"app", "core"}) {
+		t.Errorf("Expected login on app and core, got %v", found)
+	}
+	if aliases := cfg.GetContextAliases("login"); !slices.Equal(aliases, []string{"app", "core"}) {
+		t.Errorf("Expected a login context with app and core, got %v", aliases)
+	}
+
+	// The context is switched to, its branches tracking the remote ones
+	for _, name := range []string{"app", "core"} {
+		path := filepath.Join(workspace, name)
+		expectBranch(t, path, "login")
+		if upstream, err := git.NewGitRepo(path).ResolveRef("login@{upstream}"); err != nil || upstream == "" {
+			t.Errorf("Expected login to track origin/login in %s, got %q (%v)", name, upstream, err)
+		}
+	}
+	if exists, err := git.NewGitRepo(filepath.Join(workspace, "ui")).BranchExists("login"); err != nil || exists {
+		t.Errorf("Expected no login branch in ui, got %v (%v)", exists, err)
+	}
+
+	if _, err := m.FetchContext("missing"); err == nil {
+		t.Error("Expected fetching a branch no remote has to fail")
+	}
+}
@@ -0,0 +1,194 @@
+package context
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	historyFileName = "history.log"
+
+	// The history is trimmed to keepHistory entries once it grows past maxHistory
+	maxHistory  = 1000
+	keepHistory = 500
+)
+
+// HistoryEntry is a switch from one context to another. Duration is the time
+// spent in To, up to the next switch or now for the latest one.
+type HistoryEntry struct {
+	Time     time.Time
+	From     string
+	To       string
+	Duration time.Duration
+}
+
+func (e HistoryEntry) line() string {
+	return fmt.Sprintf("%s\t%s\t%s\n", e.Time.UTC().Format(time.RFC3339), e.From, e.To)
+}
+
+func (m *Manager) getHistoryFile() string {
+	return filepath.Join(".", ".alfred", historyFileName)
+}
+
+// recordSwitch appends a switch to the history log, one tab-separated line
+// per switch: time, previous context and new context
+func (m *Manager) recordSwitch(from, to string, at time.Time) error {
+	historyFile := m.getHistoryFile()
+	if err := os.MkdirAll(filepath.Dir(historyFile), 0755); err != nil {
+		return fmt.Errorf("failed to create .alfred directory: %w", err)
+	}
+
+	file, err := os.OpenFile(historyFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open history: %w", err)
+	}
+	_, err = file.WriteString(HistoryEntry{Time: at, From: from, To: to}.line())
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write history: %w", err)
+	}
+
+	entries, err := m.readHistory()
+	if err != nil || len(entries) <= maxHistory {
+		return err
+	}
+	return m.writeHistory(entries[len(entries)-keepHistory:])
+}
+
+// GetHistory returns the switches recorded in .alfred/history.log, latest
+// first, limited to limit entries when limit is positive
+func (m *Manager) GetHistory(limit int) ([]HistoryEntry, error) {
+	entries, err := m.readHistory()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	for i := range entries {
+		end := now
+		if i+1 < len(entries) {
+			end = entries[i+1].Time
+		}
+		entries[i].Duration = end.Sub(entries[i].Time)
+	}
+
+	var latest []HistoryEntry
+	for i := len(entries) - 1; i >= 0 && (limit <= 0 || len(latest) < limit); i-- {
+		latest = append(latest, entries[i])
+	}
+	return latest, nil
+}
+
+// PreviousContext returns the context used before the current one, skipping
+// contexts that were deleted since, like "cd -" does for directories
+func (m *Manager) PreviousContext() (string, error) {
+	currentContext, err := m.GetCurrentContext()
+	if err != nil {
+		return "", err
+	}
+	entries, err := m.readHistory()
+	if err != nil {
+		return "", err
+	}
+
+	for i := len(entries) - 1; i >= 0; i-- {
+		for _, name := range []string{entries[i].To, entries[i].From} {
+			if name != "" && name != currentContext && m.contextAvailable(name) {
+				return name, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("no previous context in the history")
+}
+
+// ListContextsByRecentUse returns the contexts, the most recently switched to
+// first, going by the last switch recorded for each context in the local
+// state rather than the history log, which is trimmed. Contexts never switched
+// to follow, main first and the rest by name.
+func (m *Manager) ListContextsByRecentUse() []string {
+	contexts := m.ListContexts()
+
+	sort.SliceStable(contexts, func(i, j int) bool {
+		a, b := contexts[i], contexts[j]
+		lastA, lastB := m.config.GetContextInfo(a).LastSwitched, m.config.GetContextInfo(b).LastSwitched
+		if !lastA.Equal(lastB) {
+			return lastA.After(lastB)
+		}
+		if a == "main" || b == "main" {
+			return a == "main"
+		}
+		return a < b
+	})
+	return contexts
+}
+
+// renameInHistory makes past switches to a renamed context point to its new
+// name, so "alfred switch -" keeps working
+func (m *Manager) renameInHistory(oldName, newName string) error {
+	entries, err := m.readHistory()
+	if err != nil || len(entries) == 0 {
+		return err
+	}
+	for i := range entries {
+		if entries[i].From == oldName {
+			entries[i].From = newName
+		}
+		if entries[i].To == oldName {
+			entries[i].To = newName
+		}
+	}
+	return m.writeHistory(entries)
+}
+
+func (m *Manager) contextAvailable(name string) bool {
+	return name == "main" || name == "master" || m.config.ContextExists(name)
+}
+
+// readHistory returns the switches in the order they happened, skipping
+// lines that cannot be parsed
+func (m *Manager) readHistory() ([]HistoryEntry, error) {
+	file, err := os.Open(m.getHistoryFile())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	var entries []HistoryEntry
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Split(scanner.Text(), "\t")
+		if len(fields) != 3 {
+			continue
+		}
+		at, err := time.Parse(time.RFC3339, fields[0])
+		if err != nil {
+			continue
+		}
+		entries = append(entries, HistoryEntry{Time: at, From: fields[1], To: fields[2]})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read history: %w", err)
+	}
+	return entries, nil
+}
+
+func (m *Manager) writeHistory(entries []HistoryEntry) error {
+	var b strings.Builder
+	for _, entry := range entries {
+		b.WriteString(entry.line())
+	}
+	if err := os.WriteFile(m.getHistoryFile(), []byte(b.String()), 0644); err != nil {
+		return fmt.Errorf("failed to write history: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package context
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/viniciusamelio/alfred/internal/config"
+	"github.com/viniciusamelio/alfred/internal/testutil"
+)
+
+func TestManager_History(t *testing.T) {
+	testutil.Chdir(t, t.TempDir())
+
+	cfg := &config.Config{Contexts: map[string][]string{"login": {"app"}, "pay": {"app"}, "spike": {"app"}}}
+	m := NewManager(cfg)
+
+	start := time.Now().Add(-time.Hour)
+	switches := []struct{ from, to string }{{"", "login"}, {"login", "pay"}, {"pay", "spike"}}
+	for i, s := range switches {
+		if err := m.recordSwitch(s.from, s.to, start.Add(time.Duration(i)*10*time.Minute)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := m.SetCurrentContext("spike"); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := m.GetHistory(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[0].To != "spike" || entries[1].To != "pay" {
+		t.Fatalf("Expected the latest switches first, got %+v", entries)
+	}
+	if entries[1].Duration != 10*time.Minute {
+		t.Errorf("Expected 10m spent in pay, got %s", entries[1].Duration)
+	}
+
+	if previous, err := m.PreviousContext(); err != nil || previous != "pay" {
+		t.Errorf("Expected pay as the previous context, got %q (%v)", previous, err)
+	}
+
+	// Deleted contexts are skipped and renamed ones follow their new name
+	delete(cfg.Contexts, "pay")
+	if previous, _ := m.PreviousContext(); previous != "login" {
+		t.Errorf("Expected login once pay is deleted, got %q", previous)
+	}
+	cfg.Contexts["auth"] = cfg.Contexts["login"]
+	delete(cfg.Contexts, "login")
+	if err := m.renameInHistory("login", "auth"); err != nil {
+		t.Fatal(err)
+	}
+	if previous, _ := m.PreviousContext(); previous != "auth" {
+		t.Errorf("Expected the renamed context, got %q", previous)
+	}
+}
+
+func TestManager_ListContextsByRecentUse(t *testing.T) {
+	testutil.Chdir(t, t.TempDir())
+
+	cfg := &config.Config{Contexts: map[string][]string{"login": {"app"}, "pay": {"app"}, "spike": {"app"}}}
+	m := NewManager(cfg)
+
+	// The order follows the switches recorded in the configuration, even
+	// when the history log says otherwise
+	start := time.Now().Add(-time.Hour)
+	cfg.MarkSwitched("pay", start)
+	cfg.MarkSwitched("spike", start.Add(10*time.Minute))
+	if err := m.recordSwitch("", "pay", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := strings.Join(m.ListContextsByRecentUse(), ","); got != "spike,pay,main,login" {
+		t.Errorf("Unexpected order %s", got)
+	}
+}
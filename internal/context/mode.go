@@ -0,0 +1,118 @@
+package context
+
+import (
+	"fmt"
+
+	"github.com/viniciusamelio/alfred/internal/config"
+	"github.com/viniciusamelio/alfred/internal/git"
+	"github.com/viniciusamelio/alfred/internal/status"
+	"github.com/viniciusamelio/alfred/internal/worktree"
+)
+
+// Contexts can override the global mode, so a switch may go from a context
+// checked out in the original repository paths (branch mode) to one using
+// worktrees, or the other way around. Stashes are shared by a repository and
+// its worktrees, so changes stashed in one layout are restored in the other.
+
+// leaveContext stashes the changes of the current context before switching to
+// a context in the other mode, wherever the current context keeps them: the
+// original paths of its repositories in branch mode, its worktrees and the
+// master repository in worktree mode. Switches between contexts of the same
+// mode stash as part of the switch itself.
+func (m *Manager) leaveContext(currentContext, targetContext string) error {
+	if currentContext == "" || currentContext == "main" || currentContext == "master" ||
+		m.config.GetContextMode(currentContext) == m.config.GetContextMode(targetContext) {
+		return nil
+	}
+
+	if !m.config.IsContextBranchMode(currentContext) {
+		if err := m.stashCurrentContextWorktrees(currentContext); err != nil {
+			m.logger.Warnf("Failed to stash current context: %v", err)
+		}
+
+		// The master repository is checked out in place in both modes
+		if m.config.IsContextContainsMaster(currentContext) {
+			masterRepo, err := m.config.GetMasterRepo()
+			if err != nil {
+				return fmt.Errorf("failed to get master repo: %w", err)
+			}
+			if err := m.stashRepoChanges(masterRepo, currentContext); err != nil {
+				return fmt.Errorf("failed to stash changes in %s: %w", masterRepo.Identifier(), err)
+			}
+		}
+		return nil
+	}
+
+	repos, err := m.config.GetContextRepos(currentContext)
+	if err != nil {
+		return err
+	}
+	for _, repo := range repos {
+		if err := m.stashRepoChanges(repo, currentContext); err != nil {
+			return fmt.Errorf("failed to stash changes in %s: %w", repo.Identifier(), err)
+		}
+	}
+
+	// Worktree mode expects the original paths on their main branch; the
+	// master repository is switched by the worktree mode switch itself
+	for _, repo := range repos {
+		if repo.Identifier() == m.config.Master {
+			continue
+		}
+		gitRepo := git.NewGitRepo(repo.Path)
+		if !gitRepo.IsGitRepo() {
+			continue
+		}
+		if err := m.switchRepoToMainBranch(gitRepo, repo); err != nil {
+			m.logger.Warnf("Failed to switch %s back to its main branch: %v", repo.Identifier(), err)
+		}
+	}
+	return nil
+}
+
+// releaseWorktree frees the branch of a branch-mode context that is still
+// checked out in a worktree, because the context used worktrees before. Its
+// changes are stashed for the context and restored in the original path, and
+// the worktree is removed, or only detached when untracked files are left.
+func (m *Manager) releaseWorktree(repo *config.Repository, contextName string) error {
+	worktreePath := m.worktreeManager.GetWorktreePath(repo, contextName)
+	gitRepo := git.NewGitRepo(repo.Path)
+	if exists, err := gitRepo.WorktreeExists(worktreePath); err != nil || !exists {
+		return err
+	}
+
+	info := &worktree.WorktreeInfo{Repo: repo, WorktreePath: worktreePath, BranchName: m.config.GetBranchName(contextName)}
+	if err := m.worktreeManager.HandleStashForWorktree(info, contextName, "push"); err != nil {
+		return err
+	}
+
+	// Only the pubspec backup alfred wrote may be left once changes are stashed
+	worktreeRepo := git.NewGitRepo(worktreePath)
+	if files, err := worktreeRepo.GetChangedFiles(); err == nil && !status.HasUserChanges(worktreeRepo, files) {
+		m.logger.Infof("Removing worktree %s, context %s is now in branch mode", worktreePath, contextName)
+		return gitRepo.RemoveWorktree(worktreePath)
+	}
+
+	m.logger.Warnf("Worktree %s has untracked files, detaching it instead of removing it", worktreePath)
+	return worktreeRepo.DetachHead()
+}
+
+// releaseOriginalPath frees the branch of a worktree-mode context that is
+// still checked out in the original path of a repository, because the context
+// was in branch mode before. Its changes are stashed for the context, to be
+// restored in the worktree, and the original path goes back to its main branch.
+func (m *Manager) releaseOriginalPath(repo *config.Repository, contextName string) error {
+	gitRepo := git.NewGitRepo(repo.Path)
+	if !gitRepo.IsGitRepo() {
+		return nil
+	}
+	if branch, err := gitRepo.GetCurrentBranch(); err != nil || branch != m.config.GetBranchName(contextName) {
+		return nil
+	}
+
+	if err := m.stashRepoChanges(repo, contextName); err != nil {
+		return err
+	}
+	m.logger.Infof("Moving %s to a worktree, context %s is now in worktree mode", repo.Identifier(), contextName)
+	return m.switchRepoToMainBranch(gitRepo, repo)
+}
@@ -0,0 +1,102 @@
+package context
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/viniciusamelio/alfred/internal/config"
+	"github.com/viniciusamelio/alfred/internal/git"
+	"github.com/viniciusamelio/alfred/internal/testutil"
+)
+
+func TestManager_SwitchBetweenModes(t *testing.T) {
+	testutil.SetGitIdentity(t)
+
+	workspace := t.TempDir()
+	testutil.Chdir(t, workspace)
+
+	for _, name := range []string{"app", "core"} {
+		testutil.InitRepo(t, filepath.Join(workspace, name), "main", map[string]string{"notes.txt": "base\n"})
+	}
+
+	cfg := &config.Config{
+		Repos: []config.Repository{
+			{Name: "app", Path: filepath.Join(workspace, "app")},
+			{Name: "core", Path: filepath.Join(workspace, "core")},
+		},
+		Master:     "app",
+		Mode:       config.ModeWorktree,
+		MainBranch: "main",
+		Contexts:   map[string][]string{"wide": {"app", "core"}, "small": {"app", "core"}},
+	}
+	cfg.SetContextInfo("small", config.ContextInfo{Mode: config.ModeBranch})
+	m := NewManager(cfg)
+
+	core := cfg.Repos[1].Path
+	coreWorktree := core + "-wide"
+
+	if err := m.SwitchContext("wide"); err != nil {
+		t.Fatal(err)
+	}
+	writeNotes(t, coreWorktree, "wide\n")
+
+	// Worktree to branch mode: the worktree changes are stashed and core
+	// itself moves to the branch of small
+	if err := m.SwitchContext("small"); err != nil {
+		t.Fatal(err)
+	}
+	expectBranch(t, core, "small")
+	expectNotes(t, coreWorktree, "base\n")
+	writeNotes(t, core, "small\n")
+
+	// Branch to worktree mode: core goes back to main with its changes stashed
+	if err := m.SwitchContext("wide"); err != nil {
+		t.Fatal(err)
+	}
+	expectBranch(t, core, "main")
+	expectNotes(t, core, "base\n")
+	expectNotes(t, coreWorktree, "wide\n")
+
+	if err := m.SwitchContext("small"); err != nil {
+		t.Fatal(err)
+	}
+	expectNotes(t, core, "small\n")
+
+	// A context moving to branch mode takes over the branch of its worktree
+	cfg.SetContextInfo("wide", config.ContextInfo{Mode: config.ModeBranch})
+	if err := m.SwitchContext("wide"); err != nil {
+		t.Fatal(err)
+	}
+	expectBranch(t, core, "wide")
+	expectNotes(t, core, "wide\n")
+	if _, err := os.Stat(coreWorktree); !os.IsNotExist(err) {
+		t.Errorf("Expected the worktree of wide to be removed, got %v", err)
+	}
+}
+
+func writeNotes(t *testing.T, dir, content string) {
+	t.Helper()
+
+	testutil.WriteFile(t, dir, "notes.txt", content)
+}
+
+func expectNotes(t *testing.T, dir, expected string) {
+	t.Helper()
+
+	data, err := os.ReadFile(filepath.Join(dir, "notes.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != expected {
+		t.Errorf("Expected %q in %s, got %q", expected, dir, data)
+	}
+}
+
+func expectBranch(t *testing.T, dir, expected string) {
+	t.Helper()
+
+	if branch, err := git.NewGitRepo(dir).GetCurrentBranch(); err != nil || branch != expected {
+		t.Errorf("Expected %s on %s, got %q (%v)", dir, expected, branch, err)
+	}
+}
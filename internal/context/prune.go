@@ -0,0 +1,140 @@
+package context
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/viniciusamelio/alfred/internal/git"
+)
+
+// PruneCandidate is a context that looks finished or abandoned
+type PruneCandidate struct {
+	Name     string
+	Reasons  []string // why the context can be pruned
+	Blockers []string // why it must not be pruned automatically
+}
+
+// CanPrune reports whether the context can be deleted without losing work
+func (p *PruneCandidate) CanPrune() bool {
+	return len(p.Blockers) == 0
+}
+
+// FindPruneCandidates returns contexts whose branches are merged into main in
+// every repository or, when idleDays is positive, have had no commits for that
+// many days; a branch without commits of its own does not count as merged.
+// Candidates that would lose work according to CheckDeleteSafety are returned
+// with blockers so callers can explain why they are kept. Pushed commits that
+// are not merged do not block: DeleteRemoteBranches keeps their remote copy.
+func (m *Manager) FindPruneCandidates(idleDays int) ([]*PruneCandidate, error) {
+	rows, err := m.GetAllContextsStatus()
+	if err != nil {
+		return nil, err
+	}
+
+	var candidates []*PruneCandidate
+	for _, row := range rows {
+		if row.Current {
+			continue
+		}
+
+		allMerged := true
+		anyBranch := false
+		var lastCommit time.Time
+		candidate := &PruneCandidate{Name: row.Name}
+
+		for _, st := range row.Repos {
+			if !st.BranchExists {
+				continue
+			}
+			anyBranch = true
+
+			if !st.Merged {
+				allMerged = false
+			}
+			if st.LastCommit.After(lastCommit) {
+				lastCommit = st.LastCommit
+			}
+		}
+
+		switch {
+		case !anyBranch:
+			candidate.Reasons = append(candidate.Reasons, "no branches left in any repository")
+		case allMerged:
+			candidate.Reasons = append(candidate.Reasons, "merged into main in every repository")
+		}
+
+		if anyBranch && idleDays > 0 {
+			idle := time.Since(lastCommit)
+			if idle > time.Duration(idleDays)*24*time.Hour {
+				candidate.Reasons = append(candidate.Reasons, fmt.Sprintf("idle for %d days", int(idle.Hours()/24)))
+			}
+		}
+
+		if len(candidate.Reasons) == 0 {
+			continue
+		}
+
+		risk, err := m.CheckDeleteSafety(row.Name)
+		if err != nil {
+			return nil, err
+		}
+		for _, repoRisk := range risk.Repos {
+			if repoRisk.HasRisk() {
+				candidate.Blockers = append(candidate.Blockers, fmt.Sprintf("%s: %s", repoRisk.Name, strings.Join(repoRisk.Describe(), ", ")))
+			}
+		}
+
+		candidates = append(candidates, candidate)
+	}
+
+	return candidates, nil
+}
+
+// DeleteRemoteBranches deletes the context branch from the push remote of every
+// repository in the context where it exists. Remote branches that are not
+// merged into main are kept and reported as errors.
+func (m *Manager) DeleteRemoteBranches(contextName string) error {
+	repos, err := m.config.GetContextRepos(contextName)
+	if err != nil {
+		return err
+	}
+
+	branchName := m.config.GetBranchName(contextName)
+	var errors []string
+	for _, repo := range repos {
+		gitRepo := git.NewGitRepo(repo.Path)
+		remote := repo.GetPushRemote()
+
+		exists, err := gitRepo.RemoteBranchExists(remote, branchName)
+		if err != nil {
+			errors = append(errors, fmt.Sprintf("%s: %v", repo.Name, err))
+			continue
+		}
+		if !exists {
+			continue
+		}
+
+		remoteRef := fmt.Sprintf("%s/%s", remote, branchName)
+		merged, err := gitRepo.IsMergedInto(remoteRef, m.getMainRef(repo))
+		if err != nil {
+			errors = append(errors, fmt.Sprintf("%s: cannot tell whether %s is merged, fetch it first", repo.Name, remoteRef))
+			continue
+		}
+		if !merged {
+			errors = append(errors, fmt.Sprintf("%s: %s is not merged into main, keeping it", repo.Name, remoteRef))
+			continue
+		}
+
+		if err := gitRepo.DeleteRemoteBranch(remote, branchName); err != nil {
+			errors = append(errors, fmt.Sprintf("%s: %v", repo.Name, err))
+			continue
+		}
+		m.logger.Infof("Deleted remote branch %s/%s in %s", remote, branchName, repo.Name)
+	}
+
+	if len(errors) > 0 {
+		return fmt.Errorf("failed to delete remote branches: %v", errors)
+	}
+	return nil
+}
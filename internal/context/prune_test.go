@@ -0,0 +1,80 @@
+package context
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/viniciusamelio/alfred/internal/config"
+	"github.com/viniciusamelio/alfred/internal/git"
+	"github.com/viniciusamelio/alfred/internal/testutil"
+)
+
+func TestManager_FindPruneCandidates(t *testing.T) {
+	testutil.SetGitIdentity(t)
+
+	root := t.TempDir()
+	testutil.Chdir(t, root)
+
+	remote := testutil.CreateRemote(t, root, "app", "main", nil)
+	app := filepath.Join(root, "workspace", "app")
+	testutil.RunGit(t, root, "clone", "-q", remote, app)
+
+	// done is merged into main, stale is pushed but not merged and idle, and
+	// fresh was created before main moved on, without commits of its own
+	testutil.RunGit(t, app, "branch", "fresh")
+	testutil.RunGit(t, app, "checkout", "-q", "-b", "done")
+	testutil.RunGit(t, app, "commit", "-q", "--allow-empty", "-m", "done")
+	testutil.RunGit(t, app, "push", "-q", "origin", "done")
+	testutil.RunGit(t, app, "checkout", "-q", "main")
+	testutil.RunGit(t, app, "merge", "-q", "--no-ff", "-m", "merge done", "done")
+	testutil.RunGit(t, app, "push", "-q", "origin", "main")
+
+	testutil.RunGit(t, app, "checkout", "-q", "-b", "stale")
+	t.Setenv("GIT_COMMITTER_DATE", "2020-01-01T00:00:00Z")
+	testutil.RunGit(t, app, "commit", "-q", "--allow-empty", "-m", "stale")
+	t.Setenv("GIT_COMMITTER_DATE", "")
+	testutil.RunGit(t, app, "push", "-q", "origin", "stale")
+	testutil.RunGit(t, app, "checkout", "-q", "main")
+
+	cfg := &config.Config{
+		Repos:      []config.Repository{{Name: "app", Path: app}},
+		Master:     "app",
+		Mode:       config.ModeBranch,
+		MainBranch: "main",
+		Contexts:   map[string][]string{"done": {"app"}, "fresh": {"app"}, "stale": {"app"}},
+	}
+	m := NewManager(cfg)
+
+	candidates, err := m.FindPruneCandidates(30)
+	if err != nil {
+		t.Fatal(err)
+	}
+	byName := make(map[string]*PruneCandidate)
+	for _, candidate := range candidates {
+		byName[candidate.Name] = candidate
+	}
+
+	if done := byName["done"]; done == nil || !done.CanPrune() {
+		t.Errorf("Expected done to be prunable, got %+v", done)
+	}
+	if fresh := byName["fresh"]; fresh != nil {
+		t.Errorf("Expected fresh not to be a candidate, got %+v", fresh)
+	}
+	if stale := byName["stale"]; stale == nil || !stale.CanPrune() {
+		t.Errorf("Expected idle and pushed stale to be prunable, got %+v", stale)
+	}
+
+	// The remote copy of unmerged work is never deleted
+	if err := m.DeleteRemoteBranches("stale"); err == nil {
+		t.Error("Expected deleting the unmerged remote branch to fail")
+	}
+	if exists, err := git.NewGitRepo(app).RemoteBranchExists("origin", "stale"); err != nil || !exists {
+		t.Errorf("Expected origin/stale to be kept, got %v (%v)", exists, err)
+	}
+	if err := m.DeleteRemoteBranches("done"); err != nil {
+		t.Fatal(err)
+	}
+	if exists, _ := git.NewGitRepo(app).RemoteBranchExists("origin", "done"); exists {
+		t.Error("Expected origin/done to be deleted")
+	}
+}
@@ -0,0 +1,198 @@
+package context
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/viniciusamelio/alfred/internal/config"
+	"github.com/viniciusamelio/alfred/internal/git"
+)
+
+// RenameOptions controls how a context is renamed
+type RenameOptions struct {
+	// Remote also renames the branches on the push remote of each repository
+	Remote bool
+}
+
+// RenameContext renames a context together with its branches, worktree
+// directories and alfred stashes. Every step is undone if a later one fails.
+func (m *Manager) RenameContext(oldName, newName string, opts RenameOptions) error {
+	if newName == "" {
+		return fmt.Errorf("new context name cannot be empty")
+	}
+
+	state, err := m.GetSyncState()
+	if err != nil {
+		return err
+	}
+	if state != nil && state.Context == oldName {
+		return fmt.Errorf("a sync of context '%s' is in progress. Finish or abort it first", oldName)
+	}
+
+	currentContext, err := m.GetCurrentContext()
+	if err != nil {
+		return fmt.Errorf("failed to get current context: %w", err)
+	}
+
+	repos, err := m.config.GetContextRepos(oldName)
+	if err != nil {
+		return err
+	}
+
+	oldBranch := m.config.GetBranchName(oldName)
+	if err := m.config.RenameContext(oldName, newName); err != nil {
+		return err
+	}
+	newBranch := m.config.GetBranchName(newName)
+
+	m.logger.Infof("Renaming context %s to %s (branch %s -> %s)", oldName, newName, oldBranch, newBranch)
+
+	// Undo steps run in reverse order when something fails
+	var undo []func() error
+	rollback := func(cause error) error {
+		m.logger.Warnf("Rename failed, rolling back: %v", cause)
+		for i := len(undo) - 1; i >= 0; i-- {
+			if err := undo[i](); err != nil {
+				m.logger.Warnf("Failed to roll back: %v", err)
+			}
+		}
+		return cause
+	}
+
+	saved := false
+	undo = append(undo, func() error {
+		if err := m.config.RenameContext(newName, oldName); err != nil {
+			return err
+		}
+		if saved {
+			return m.config.Save()
+		}
+		return nil
+	})
+
+	movedWorktrees := false
+	oldStash := fmt.Sprintf("alfred-context-%s", oldName)
+	newStash := fmt.Sprintf("alfred-context-%s", newName)
+
+	for _, repo := range repos {
+		repoIdentifier := repo.Identifier()
+
+		gitRepo := git.NewGitRepo(repo.Path)
+		if !gitRepo.IsGitRepo() {
+			continue
+		}
+
+		if oldBranch != newBranch {
+			exists, err := gitRepo.BranchExists(oldBranch)
+			if err != nil {
+				return rollback(fmt.Errorf("%s: %w", repoIdentifier, err))
+			}
+			if exists {
+				if err := gitRepo.RenameBranch(oldBranch, newBranch); err != nil {
+					return rollback(fmt.Errorf("%s: %w", repoIdentifier, err))
+				}
+				undo = append(undo, func() error { return gitRepo.RenameBranch(newBranch, oldBranch) })
+				m.logger.Infof("Renamed branch %s to %s in %s", oldBranch, newBranch, repoIdentifier)
+			}
+		}
+
+		if _, usesWorktree := m.getBranchCheckout(repo, oldName, oldBranch, ""); usesWorktree {
+			oldPath := m.worktreeManager.GetWorktreePath(repo, oldName)
+			newPath := m.worktreeManager.GetWorktreePath(repo, newName)
+			if oldPath != newPath && git.NewGitRepo(oldPath).IsGitRepo() {
+				if _, err := os.Stat(newPath); err == nil {
+					return rollback(fmt.Errorf("%s: %s already exists", repoIdentifier, newPath))
+				}
+				if err := os.MkdirAll(filepath.Dir(newPath), 0755); err != nil {
+					return rollback(fmt.Errorf("%s: failed to create worktree directory: %w", repoIdentifier, err))
+				}
+				if err := gitRepo.MoveWorktree(oldPath, newPath); err != nil {
+					return rollback(fmt.Errorf("%s: %w", repoIdentifier, err))
+				}
+				undo = append(undo, func() error { return gitRepo.MoveWorktree(newPath, oldPath) })
+				m.logger.Infof("Moved worktree %s to %s", oldPath, newPath)
+				movedWorktrees = true
+			}
+		}
+
+		count, err := gitRepo.RetagStashes(oldStash, newStash)
+		if count > 0 {
+			undo = append(undo, func() error {
+				_, err := gitRepo.RetagStashes(newStash, oldStash)
+				return err
+			})
+			m.logger.Infof("Renamed %d alfred stashes in %s", count, repoIdentifier)
+		}
+		if err != nil {
+			return rollback(fmt.Errorf("%s: %w", repoIdentifier, err))
+		}
+	}
+
+	// Push the new remote branches first; the old ones are only deleted once
+	// everything else succeeded
+	var pushed []*config.Repository
+	if opts.Remote && oldBranch != newBranch {
+		for _, repo := range repos {
+			gitRepo := git.NewGitRepo(repo.Path)
+			remote := repo.GetPushRemote()
+
+			exists, err := gitRepo.RemoteBranchExists(remote, oldBranch)
+			if err != nil {
+				return rollback(fmt.Errorf("%s: %w", repo.Name, err))
+			}
+			if !exists {
+				continue
+			}
+
+			if err := gitRepo.PushBranch(remote, newBranch); err != nil {
+				return rollback(fmt.Errorf("%s: %w", repo.Name, err))
+			}
+			undo = append(undo, func() error { return gitRepo.DeleteRemoteBranch(remote, newBranch) })
+			pushed = append(pushed, repo)
+
+			tracking := gitRepo.GetBranchTracking(newBranch)
+			if tracking.Remote == remote && tracking.Merge == oldBranch {
+				if err := gitRepo.SetBranchUpstream(newBranch, fmt.Sprintf("%s/%s", remote, newBranch)); err != nil {
+					m.logger.Warnf("Failed to update upstream in %s: %v", repo.Name, err)
+				}
+			}
+		}
+	}
+
+	if err := m.config.Save(); err != nil {
+		return rollback(fmt.Errorf("failed to save config: %w", err))
+	}
+	saved = true
+
+	if currentContext == oldName {
+		if err := m.SetCurrentContext(newName); err != nil {
+			return rollback(fmt.Errorf("failed to update current context: %w", err))
+		}
+	}
+
+	if err := m.renameInHistory(oldName, newName); err != nil {
+		m.logger.Warnf("Failed to rename the context in the history: %v", err)
+	}
+
+	for _, repo := range pushed {
+		if err := git.NewGitRepo(repo.Path).DeleteRemoteBranch(repo.GetPushRemote(), oldBranch); err != nil {
+			m.logger.Warnf("Failed to delete old remote branch in %s: %v", repo.Name, err)
+		}
+	}
+
+	// Worktree paths changed, so the path dependencies of the active context
+	// must follow. An inactive context keeps its linked pubspecs in its alfred
+	// stashes, which are relinked when switching to it: relinking its
+	// worktrees now would conflict with them.
+	if currentContext == oldName {
+		if err := m.RelinkContext(newName); err != nil {
+			m.logger.Warnf("Failed to relink context, run 'alfred switch %s' to fix dependencies: %v", newName, err)
+		}
+	} else if movedWorktrees {
+		m.logger.Infof("The path dependencies of %s follow its new worktree paths the next time you switch to it", newName)
+	}
+
+	m.logger.Infof("Renamed context %s to %s", oldName, newName)
+	return nil
+}
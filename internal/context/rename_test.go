@@ -0,0 +1,115 @@
+package context
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/viniciusamelio/alfred/internal/config"
+	"github.com/viniciusamelio/alfred/internal/git"
+	"github.com/viniciusamelio/alfred/internal/testutil"
+)
+
+func TestManager_RenameContextRollsBack(t *testing.T) {
+	testutil.SetGitIdentity(t)
+
+	workspace := t.TempDir()
+	testutil.Chdir(t, workspace)
+
+	var repos []config.Repository
+	for _, name := range []string{"app", "core", "ui"} {
+		path := filepath.Join(workspace, name)
+		testutil.InitRepo(t, path, "main", nil)
+		repos = append(repos, config.Repository{Name: name, Path: path})
+	}
+
+	cfg := &config.Config{
+		Repos:      repos,
+		Master:     "app",
+		Mode:       config.ModeWorktree,
+		MainBranch: "main",
+		Contexts:   map[string][]string{"login": {"app", "core", "ui"}},
+	}
+	m := NewManager(cfg)
+	if err := m.SwitchContext("login"); err != nil {
+		t.Fatal(err)
+	}
+
+	// The worktree of ui cannot be moved, after app and core were renamed
+	testutil.WriteFile(t, workspace, "ui-auth", "taken\n")
+
+	if err := m.RenameContext("login", "auth", RenameOptions{}); err == nil {
+		t.Fatal("Expected the rename to fail")
+	}
+
+	for _, repo := range repos {
+		gitRepo := git.NewGitRepo(repo.Path)
+		if exists, err := gitRepo.BranchExists("login"); err != nil || !exists {
+			t.Errorf("Expected login to be restored in %s, got %v (%v)", repo.Name, exists, err)
+		}
+		if exists, err := gitRepo.BranchExists("auth"); err != nil || exists {
+			t.Errorf("Expected no auth branch left in %s, got %v (%v)", repo.Name, exists, err)
+		}
+	}
+	for _, name := range []string{"core", "ui"} {
+		expectBranch(t, filepath.Join(workspace, name+"-login"), "login")
+	}
+	if _, err := os.Stat(filepath.Join(workspace, "core-auth")); !os.IsNotExist(err) {
+		t.Errorf("Expected the worktree of core to be moved back, got %v", err)
+	}
+
+	if !cfg.ContextExists("login") || cfg.ContextExists("auth") {
+		t.Errorf("Expected the configuration to keep login, got %v", cfg.Contexts)
+	}
+	if current, err := m.GetCurrentContext(); err != nil || current != "login" {
+		t.Errorf("Expected login to stay the current context, got %q (%v)", current, err)
+	}
+}
+
+func TestManager_RenameInactiveContextRelinksOnSwitch(t *testing.T) {
+	testutil.SetGitIdentity(t)
+
+	workspace := t.TempDir()
+	testutil.Chdir(t, workspace)
+
+	corePubspec := "name: core\ndependencies:\n  ui:\n    git:\n      url: git@example.com:ui.git\n      ref: main\n"
+	testutil.InitRepo(t, filepath.Join(workspace, "app"), "main", nil)
+	testutil.InitRepo(t, filepath.Join(workspace, "core"), "main", map[string]string{"pubspec.yaml": corePubspec})
+	testutil.InitRepo(t, filepath.Join(workspace, "ui"), "main", map[string]string{"pubspec.yaml": "name: ui\n"})
+
+	cfg := &config.Config{
+		Repos: []config.Repository{
+			{Name: "app", Path: filepath.Join(workspace, "app")},
+			{Name: "core", Path: filepath.Join(workspace, "core")},
+			{Name: "ui", Path: filepath.Join(workspace, "ui")},
+		},
+		Master:     "app",
+		Mode:       config.ModeWorktree,
+		MainBranch: "main",
+		Contexts:   map[string][]string{"login": {"app", "core", "ui"}, "pay": {"app"}},
+	}
+	m := NewManager(cfg)
+	for _, name := range []string{"login", "pay"} {
+		if err := m.SwitchContext(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The links of login were stashed when leaving it, and follow the moved
+	// worktrees once it is switched to again
+	if err := m.RenameContext("login", "auth", RenameOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.SwitchContext("auth"); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(workspace, "core-auth", "pubspec.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "path: ../ui-auth") || strings.Contains(string(data), "ui-login") {
+		t.Errorf("Expected core to depend on the worktree of ui for auth, got:\n%s", data)
+	}
+}
@@ -0,0 +1,210 @@
+package context
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/viniciusamelio/alfred/internal/config"
+	"github.com/viniciusamelio/alfred/internal/git"
+	"github.com/viniciusamelio/alfred/internal/pubspec"
+)
+
+// AddRepoToContext adds a repository to an existing context. Its worktree or
+// branch is created right away, and if the context is active its pubspecs are
+// linked to the rest of the context.
+func (m *Manager) AddRepoToContext(contextName, alias string) error {
+	if err := m.config.AddRepoToContext(contextName, alias); err != nil {
+		return err
+	}
+
+	repo, err := m.config.GetRepoByAlias(alias)
+	if err != nil {
+		return err
+	}
+
+	currentContext, err := m.GetCurrentContext()
+	if err != nil {
+		return fmt.Errorf("failed to get current context: %w", err)
+	}
+	active := currentContext == contextName
+	branchName := m.config.GetBranchName(contextName)
+
+	gitRepo := git.NewGitRepo(repo.Path)
+	if !gitRepo.IsGitRepo() {
+		return fmt.Errorf("repository %s is not a git repository", alias)
+	}
+
+	_, usesWorktree := m.getBranchCheckout(repo, contextName, branchName, "")
+	switch {
+	case usesWorktree:
+		// Worktrees of a context exist whether it is active or not
+		if _, err := m.worktreeManager.CreateWorktreeForContext(repo, contextName); err != nil {
+			return fmt.Errorf("failed to create worktree for %s: %w", alias, err)
+		}
+	case active:
+		var err error
+		if alias == m.config.Master {
+			err = m.switchMasterRepoToContext(repo, contextName)
+		} else {
+			err = m.switchRepoToContext(repo, contextName)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to switch %s to branch %s: %w", alias, branchName, err)
+		}
+	}
+
+	if err := m.config.Save(); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+	m.logger.Infof("Added %s to context %s", alias, contextName)
+
+	if active {
+		return m.RelinkContext(contextName)
+	}
+	return nil
+}
+
+// RemoveRepoFromContext removes a repository from a context together with its
+// worktree and branch. Callers are expected to check CheckDeleteSafety first.
+func (m *Manager) RemoveRepoFromContext(contextName, alias string, opts DeleteOptions) error {
+	if !m.config.ContextContainsRepo(contextName, alias) {
+		return fmt.Errorf("repository '%s' is not in context '%s'", alias, contextName)
+	}
+
+	repo, err := m.config.GetRepoByAlias(alias)
+	if err != nil {
+		return err
+	}
+
+	currentContext, err := m.GetCurrentContext()
+	if err != nil {
+		return fmt.Errorf("failed to get current context: %w", err)
+	}
+	active := currentContext == contextName
+
+	var repoRisk *RepoDeleteRisk
+	if opts.Backup {
+		risk, err := m.CheckDeleteSafety(contextName)
+		if err != nil {
+			return err
+		}
+		for _, r := range risk.Repos {
+			if r.Name == alias {
+				repoRisk = r
+			}
+		}
+	}
+
+	siblings, err := m.config.GetContextRepos(contextName)
+	if err != nil {
+		return err
+	}
+
+	// The config refuses some removals, such as the last repository of a
+	// context, so it is updated before touching git or the filesystem and
+	// restored if a later step fails
+	previousRefs := slices.Clone(m.config.Contexts[contextName])
+	if err := m.config.RemoveRepoFromContext(contextName, alias); err != nil {
+		return err
+	}
+	if err := m.removeRepoCheckout(repo, contextName, siblings, active, repoRisk); err != nil {
+		m.config.Contexts[contextName] = previousRefs
+		return err
+	}
+
+	if err := m.config.Save(); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+	m.logger.Infof("Removed %s from context %s", alias, contextName)
+
+	if active {
+		return m.RelinkContext(contextName)
+	}
+	return nil
+}
+
+// removeRepoCheckout backs up the work of a repository removed from a context
+// when repoRisk is set, unlinks it from its siblings and removes its worktree
+// and branch
+func (m *Manager) removeRepoCheckout(repo *config.Repository, contextName string, siblings []*config.Repository, active bool, repoRisk *RepoDeleteRisk) error {
+	alias := repo.Identifier()
+	branchName := m.config.GetBranchName(contextName)
+
+	if repoRisk != nil {
+		if err := m.backupRepo(repo, repoRisk, branchName, m.trashRef(contextName)); err != nil {
+			return fmt.Errorf("failed to back up %s: %w", alias, err)
+		}
+	}
+
+	gitRepo := git.NewGitRepo(repo.Path)
+	_, usesWorktree := m.getBranchCheckout(repo, contextName, branchName, "")
+
+	if active {
+		// Point the other repositories back to the git dependency on the removed one
+		for _, sibling := range siblings {
+			if sibling.Name == repo.Name {
+				continue
+			}
+			m.unlinkDependency(m.worktreeManager.GetRepoPath(sibling, contextName), repo.Name)
+		}
+
+		// A repository switched in place goes back to its main branch, unlinked first
+		// so its pubspec does not keep pointing at the context. Other local changes
+		// are carried over by the checkout.
+		if !usesWorktree && gitRepo.IsGitRepo() {
+			for _, sibling := range siblings {
+				if sibling.Name != repo.Name {
+					m.unlinkDependency(repo.Path, sibling.Name)
+				}
+			}
+
+			if err := m.switchRepoToMainBranch(gitRepo, repo); err != nil {
+				return fmt.Errorf("failed to switch %s to its main branch: %w", alias, err)
+			}
+		}
+	}
+
+	if usesWorktree {
+		if err := m.worktreeManager.RemoveWorktreeForContext(repo, contextName); err != nil {
+			return fmt.Errorf("failed to remove worktree for %s: %w", alias, err)
+		}
+	}
+
+	if err := m.deleteBranchIfExists(repo, branchName); err != nil {
+		return fmt.Errorf("failed to delete branch %s in %s: %w", branchName, alias, err)
+	}
+	return nil
+}
+
+// unlinkDependency turns the path dependency on depName in the pubspec at
+// repoPath back into the git dependency it replaced
+func (m *Manager) unlinkDependency(repoPath, depName string) {
+	if _, err := os.Stat(filepath.Join(repoPath, "pubspec.yaml")); err != nil {
+		return
+	}
+
+	pubspecFile, err := pubspec.LoadPubspec(repoPath)
+	if err != nil {
+		m.logger.Warnf("Failed to load pubspec.yaml in %s: %v", repoPath, err)
+		return
+	}
+
+	if pubspecFile.GetDependencySource(depName) != pubspec.DependencyPath {
+		return
+	}
+
+	if err := pubspecFile.UncommentGitDependencyAndRemovePath(depName); err != nil {
+		if err := pubspecFile.ConvertPathToGitFromBackup(depName); err != nil {
+			m.logger.Warnf("Failed to restore git dependency %s in %s: %v", depName, repoPath, err)
+			return
+		}
+	}
+
+	if err := pubspecFile.Save(); err != nil {
+		m.logger.Warnf("Failed to save pubspec.yaml in %s: %v", repoPath, err)
+		return
+	}
+	m.logger.Infof("Restored git dependency %s in %s", depName, repoPath)
+}
@@ -0,0 +1,41 @@
+package context
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/viniciusamelio/alfred/internal/config"
+	"github.com/viniciusamelio/alfred/internal/git"
+	"github.com/viniciusamelio/alfred/internal/testutil"
+)
+
+func TestManager_RemoveLastRepoFromContext(t *testing.T) {
+	testutil.SetGitIdentity(t)
+
+	workspace := t.TempDir()
+	testutil.Chdir(t, workspace)
+
+	core := filepath.Join(workspace, "core")
+	testutil.InitRepo(t, core, "main", nil)
+	testutil.RunGit(t, core, "branch", "solo")
+
+	cfg := &config.Config{
+		Repos:      []config.Repository{{Name: "core", Path: core}},
+		Mode:       config.ModeBranch,
+		MainBranch: "main",
+		Contexts:   map[string][]string{"solo": {"core"}},
+	}
+	m := NewManager(cfg)
+
+	if err := m.RemoveRepoFromContext("solo", "core", DeleteOptions{}); err == nil {
+		t.Fatal("Expected removing the last repository of a context to fail")
+	}
+
+	// The branch must be left alone when the config refuses the removal
+	if exists, err := git.NewGitRepo(core).BranchExists("solo"); err != nil || !exists {
+		t.Errorf("Expected the branch of solo to be kept, got %v (%v)", exists, err)
+	}
+	if !cfg.ContextContainsRepo("solo", "core") {
+		t.Error("Expected core to stay in solo")
+	}
+}
@@ -0,0 +1,315 @@
+package context
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/viniciusamelio/alfred/internal/git"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	SyncRebase = "rebase"
+	SyncMerge  = "merge"
+
+	SyncStatusPending  = "pending"
+	SyncStatusDone     = "done"
+	SyncStatusConflict = "conflict"
+)
+
+// SyncRepoState tracks the progress of a single repository during a sync
+type SyncRepoState struct {
+	Name     string `yaml:"name"`
+	Path     string `yaml:"path"`
+	Onto     string `yaml:"onto"`
+	OrigHead string `yaml:"orig_head"`
+	Status   string `yaml:"status"`
+}
+
+// SyncState is persisted under .alfred so a sync can be continued or aborted
+type SyncState struct {
+	Context  string          `yaml:"context"`
+	Strategy string          `yaml:"strategy"`
+	Repos    []SyncRepoState `yaml:"repos"`
+}
+
+// SyncConflictError reports the repository a sync is waiting on
+type SyncConflictError struct {
+	Repo string
+	Path string
+	Err  error
+}
+
+func (e *SyncConflictError) Error() string {
+	return fmt.Sprintf("sync stopped in %s (%s): %v", e.Repo, e.Path, e.Err)
+}
+
+func (m *Manager) getSyncStateFile() string {
+	return filepath.Join(".", ".alfred", "sync-state.yaml")
+}
+
+// GetSyncState returns the sync in progress, or nil if there is none
+func (m *Manager) GetSyncState() (*SyncState, error) {
+	data, err := os.ReadFile(m.getSyncStateFile())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read sync state: %w", err)
+	}
+
+	var state SyncState
+	if err := yaml.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("failed to parse sync state: %w", err)
+	}
+	return &state, nil
+}
+
+func (m *Manager) saveSyncState(state *SyncState) error {
+	data, err := yaml.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("failed to marshal sync state: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(m.getSyncStateFile()), 0755); err != nil {
+		return fmt.Errorf("failed to create .alfred directory: %w", err)
+	}
+
+	if err := os.WriteFile(m.getSyncStateFile(), data, 0644); err != nil {
+		return fmt.Errorf("failed to write sync state: %w", err)
+	}
+	return nil
+}
+
+func (m *Manager) clearSyncState() error {
+	if err := os.Remove(m.getSyncStateFile()); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove sync state: %w", err)
+	}
+	return nil
+}
+
+// SyncContext fetches every repository of the current context and rebases or
+// merges its branch onto the latest main branch of its remote
+func (m *Manager) SyncContext(strategy string) (*SyncState, error) {
+	if strategy != SyncRebase && strategy != SyncMerge {
+		return nil, fmt.Errorf("invalid sync strategy '%s'. Must be 'rebase' or 'merge'", strategy)
+	}
+
+	existing, err := m.GetSyncState()
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return existing, fmt.Errorf("a sync of context '%s' is already in progress. Use 'alfred sync --continue' or 'alfred sync --abort'", existing.Context)
+	}
+
+	currentContext, err := m.GetCurrentContext()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current context: %w", err)
+	}
+	if currentContext == "" {
+		return nil, fmt.Errorf("no context is currently active. Use 'alfred switch' to activate a context")
+	}
+
+	repos, err := m.config.GetContextRepos(currentContext)
+	if err != nil {
+		return nil, err
+	}
+
+	state := &SyncState{
+		Context:  currentContext,
+		Strategy: strategy,
+	}
+
+	// Fetch everything up front so nothing is touched if a remote is unreachable
+	for _, repo := range repos {
+		repoIdentifier := repo.Identifier()
+
+		repoPath := m.worktreeManager.GetRepoPath(repo, currentContext)
+		gitRepo := git.NewGitRepo(repoPath)
+		if !gitRepo.IsGitRepo() {
+			return nil, fmt.Errorf("%s (%s) is not a git repository. Switch to the context first", repoIdentifier, repoPath)
+		}
+
+		m.logger.Infof("Fetching %s in %s", repo.GetRemote(), repoIdentifier)
+		if err := gitRepo.Fetch(repo.GetRemote()); err != nil {
+			return nil, fmt.Errorf("%s: %w", repoIdentifier, err)
+		}
+
+		onto := fmt.Sprintf("%s/%s", repo.GetRemote(), m.config.GetRepoMainBranch(repo))
+		if _, err := gitRepo.ResolveRef(onto); err != nil {
+			return nil, fmt.Errorf("%s: %s does not exist", repoIdentifier, onto)
+		}
+
+		origHead, err := gitRepo.GetHeadCommit()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", repoIdentifier, err)
+		}
+
+		state.Repos = append(state.Repos, SyncRepoState{
+			Name:     repoIdentifier,
+			Path:     repoPath,
+			Onto:     onto,
+			OrigHead: origHead,
+			Status:   SyncStatusPending,
+		})
+	}
+
+	if err := m.saveSyncState(state); err != nil {
+		return nil, err
+	}
+
+	return state, m.runSync(state)
+}
+
+// ContinueSync resumes a sync after the conflicts in the waiting repository were resolved
+func (m *Manager) ContinueSync() (*SyncState, error) {
+	state, err := m.GetSyncState()
+	if err != nil {
+		return nil, err
+	}
+	if state == nil {
+		return nil, fmt.Errorf("no sync in progress")
+	}
+
+	for i := range state.Repos {
+		repoState := &state.Repos[i]
+		if repoState.Status != SyncStatusConflict {
+			continue
+		}
+
+		gitRepo := git.NewGitRepo(repoState.Path)
+		if hasConflicts, err := gitRepo.HasConflicts(); err == nil && hasConflicts {
+			return state, &SyncConflictError{Repo: repoState.Name, Path: repoState.Path,
+				Err: fmt.Errorf("there are still unresolved conflicts. Resolve and stage them first")}
+		}
+
+		var err error
+		switch {
+		case gitRepo.IsRebaseInProgress():
+			err = gitRepo.ContinueRebase()
+		case gitRepo.IsMergeInProgress():
+			err = gitRepo.ContinueMerge()
+		}
+
+		if err != nil {
+			if gitRepo.IsRebaseInProgress() || gitRepo.IsMergeInProgress() {
+				// The next commit of the rebase conflicted as well
+				if saveErr := m.saveSyncState(state); saveErr != nil {
+					return state, saveErr
+				}
+				return state, &SyncConflictError{Repo: repoState.Name, Path: repoState.Path, Err: err}
+			}
+			return state, fmt.Errorf("%s: %w", repoState.Name, err)
+		}
+
+		// The rebase or merge may have been aborted by hand instead of finished
+		if synced, err := gitRepo.IsMergedInto(repoState.Onto, "HEAD"); err != nil || !synced {
+			return state, fmt.Errorf("%s is not synced: %s is not part of its branch. Run 'alfred sync --abort' and sync again", repoState.Name, repoState.Onto)
+		}
+
+		repoState.Status = SyncStatusDone
+		if err := m.saveSyncState(state); err != nil {
+			return state, err
+		}
+	}
+
+	return state, m.runSync(state)
+}
+
+// AbortSync aborts the in-progress rebase or merge and moves every repository
+// that was already synced back to where it was before the sync started
+func (m *Manager) AbortSync() (*SyncState, error) {
+	state, err := m.GetSyncState()
+	if err != nil {
+		return nil, err
+	}
+	if state == nil {
+		return nil, fmt.Errorf("no sync in progress")
+	}
+
+	var errors []string
+	for i := range state.Repos {
+		repoState := &state.Repos[i]
+		gitRepo := git.NewGitRepo(repoState.Path)
+
+		switch repoState.Status {
+		case SyncStatusConflict:
+			var err error
+			if gitRepo.IsRebaseInProgress() {
+				err = gitRepo.AbortRebase()
+			} else if gitRepo.IsMergeInProgress() {
+				err = gitRepo.AbortMerge()
+			}
+			if err != nil {
+				errors = append(errors, fmt.Sprintf("%s: %v", repoState.Name, err))
+				continue
+			}
+		case SyncStatusDone:
+			if err := gitRepo.ResetKeep(repoState.OrigHead); err != nil {
+				errors = append(errors, fmt.Sprintf("%s: %v", repoState.Name, err))
+				continue
+			}
+		}
+
+		m.logger.Infof("Restored %s to its state before the sync", repoState.Name)
+		repoState.Status = SyncStatusPending
+	}
+
+	if len(errors) > 0 {
+		if err := m.saveSyncState(state); err != nil {
+			return state, err
+		}
+		return state, fmt.Errorf("failed to abort sync in some repositories: %v", errors)
+	}
+
+	return state, m.clearSyncState()
+}
+
+// runSync processes the pending repositories in order, stopping at the first conflict
+func (m *Manager) runSync(state *SyncState) error {
+	for i := range state.Repos {
+		repoState := &state.Repos[i]
+		if repoState.Status == SyncStatusDone {
+			continue
+		}
+
+		gitRepo := git.NewGitRepo(repoState.Path)
+		m.logger.Infof("Updating %s onto %s (%s)", repoState.Name, repoState.Onto, state.Strategy)
+
+		var err error
+		if state.Strategy == SyncMerge {
+			err = gitRepo.Merge(repoState.Onto)
+		} else {
+			err = gitRepo.Rebase(repoState.Onto)
+		}
+
+		if err != nil {
+			if gitRepo.IsRebaseInProgress() || gitRepo.IsMergeInProgress() {
+				repoState.Status = SyncStatusConflict
+				if saveErr := m.saveSyncState(state); saveErr != nil {
+					return saveErr
+				}
+				return &SyncConflictError{Repo: repoState.Name, Path: repoState.Path, Err: err}
+			}
+
+			if saveErr := m.saveSyncState(state); saveErr != nil {
+				return saveErr
+			}
+			return fmt.Errorf("%s: %w", repoState.Name, err)
+		}
+
+		repoState.Status = SyncStatusDone
+		if err := m.saveSyncState(state); err != nil {
+			return err
+		}
+	}
+
+	if err := m.clearSyncState(); err != nil {
+		return err
+	}
+
+	// Updated branches may have changed pubspec.yaml, so link the context again
+	return m.RelinkContext(state.Context)
+}
@@ -0,0 +1,129 @@
+package context
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/viniciusamelio/alfred/internal/config"
+	"github.com/viniciusamelio/alfred/internal/git"
+	"github.com/viniciusamelio/alfred/internal/testutil"
+)
+
+func TestManager_SyncConflict(t *testing.T) {
+	testutil.SetGitIdentity(t)
+
+	root := t.TempDir()
+	workspace := filepath.Join(root, "workspace")
+	testutil.Chdir(t, root)
+
+	// Every repository commits to notes.txt on login while main moves on
+	// upstream; only core changes notes.txt on main as well and conflicts
+	var repos []config.Repository
+	for _, name := range []string{"app", "core", "ui"} {
+		remote := testutil.CreateRemote(t, root, name, "main", map[string]string{"notes.txt": "base\n"})
+		seed := filepath.Join(root, "seed", name)
+		if name == "core" {
+			testutil.WriteFile(t, seed, "notes.txt", "main\n")
+		} else {
+			testutil.WriteFile(t, seed, "other.txt", "main\n")
+		}
+
+		path := filepath.Join(workspace, name)
+		testutil.RunGit(t, root, "clone", "-q", remote, path)
+		testutil.RunGit(t, path, "checkout", "-q", "-b", "login")
+		writeNotes(t, path, "login\n")
+		testutil.RunGit(t, path, "commit", "-q", "-am", "login")
+
+		testutil.RunGit(t, seed, "add", ".")
+		testutil.RunGit(t, seed, "commit", "-q", "-m", "main")
+		testutil.RunGit(t, seed, "push", "-q", remote, "main")
+
+		repos = append(repos, config.Repository{Name: name, Path: path})
+	}
+
+	cfg := &config.Config{
+		Repos:      repos,
+		Master:     "app",
+		Mode:       config.ModeBranch,
+		MainBranch: "main",
+		Contexts:   map[string][]string{"login": {"app", "core", "ui"}},
+	}
+	m := NewManager(cfg)
+	if err := m.SetCurrentContext("login"); err != nil {
+		t.Fatal(err)
+	}
+
+	heads := make(map[string]string)
+	for _, repo := range repos {
+		head, err := git.NewGitRepo(repo.Path).GetHeadCommit()
+		if err != nil {
+			t.Fatal(err)
+		}
+		heads[repo.Name] = head
+	}
+
+	// app is rebased, then the sync stops in core and leaves ui alone
+	expectSyncConflict(t, m, "core", func() error { _, err := m.SyncContext(SyncRebase); return err })
+	if head, _ := git.NewGitRepo(repos[0].Path).GetHeadCommit(); head == heads["app"] {
+		t.Error("Expected app to be rebased before the conflict")
+	}
+	expectSyncConflict(t, m, "core", func() error { _, err := m.ContinueSync(); return err })
+
+	// A rebase aborted by hand leaves core unsynced, which continuing must not hide
+	testutil.RunGit(t, repos[1].Path, "rebase", "--abort")
+	if _, err := m.ContinueSync(); err == nil || !strings.Contains(err.Error(), "core is not synced") {
+		t.Errorf("Expected core to be reported as not synced, got %v", err)
+	}
+	if state, err := m.GetSyncState(); err != nil || state == nil || state.Repos[1].Status != SyncStatusConflict {
+		t.Errorf("Expected core to stay in conflict, got %+v (%v)", state, err)
+	}
+
+	// Aborting moves app back and ends the rebase of core
+	if _, err := m.AbortSync(); err != nil {
+		t.Fatal(err)
+	}
+	for _, repo := range repos {
+		gitRepo := git.NewGitRepo(repo.Path)
+		if head, _ := gitRepo.GetHeadCommit(); head != heads[repo.Name] {
+			t.Errorf("Expected %s back on its commit before the sync", repo.Name)
+		}
+		if gitRepo.IsRebaseInProgress() {
+			t.Errorf("Expected the rebase of %s to be aborted", repo.Name)
+		}
+	}
+	if state, err := m.GetSyncState(); err != nil || state != nil {
+		t.Errorf("Expected no sync in progress after the abort, got %+v (%v)", state, err)
+	}
+
+	// Once core is resolved, continuing syncs it and ui
+	expectSyncConflict(t, m, "core", func() error { _, err := m.SyncContext(SyncRebase); return err })
+	writeNotes(t, repos[1].Path, "main\nlogin\n")
+	testutil.RunGit(t, repos[1].Path, "add", "notes.txt")
+	if _, err := m.ContinueSync(); err != nil {
+		t.Fatal(err)
+	}
+	expectNotes(t, repos[1].Path, "main\nlogin\n")
+	for _, repo := range repos {
+		if merged, err := git.NewGitRepo(repo.Path).IsMergedInto("origin/main", "login"); err != nil || !merged {
+			t.Errorf("Expected login to be on top of origin/main in %s (%v)", repo.Name, err)
+		}
+	}
+	if state, err := m.GetSyncState(); err != nil || state != nil {
+		t.Errorf("Expected the sync to be finished, got %+v (%v)", state, err)
+	}
+}
+
+func expectSyncConflict(t *testing.T, m *Manager, repo string, sync func() error) {
+	t.Helper()
+
+	var conflict *SyncConflictError
+	if err := sync(); !errors.As(err, &conflict) || conflict.Repo != repo {
+		t.Fatalf("Expected a conflict in %s, got %v", repo, err)
+	}
+	state, err := m.GetSyncState()
+	if err != nil || state == nil {
+		t.Fatalf("Expected the sync state to be kept, got %v", err)
+	}
+}
@@ -163,6 +163,16 @@ func (g *GitRepo) GetFileContent(filePath string) (string, error) {
 	return string(content), nil
 }
 
+// GetCommittedFileContent returns the content of a file at HEAD
+func (g *GitRepo) GetCommittedFileContent(filePath string) (string, error) {
+	cmd := exec.Command("git", "-C", g.Path, "show", "HEAD:"+filepath.ToSlash(filePath))
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to read committed file %s: %w", filePath, err)
+	}
+	return string(output), nil
+}
+
 // GetStatusDescription returns a human-readable description of the file status
 func GetStatusDescription(status string) string {
 	switch status {
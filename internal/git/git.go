@@ -16,6 +16,20 @@ func NewGitRepo(path string) *GitRepo {
 	return &GitRepo{Path: path}
 }
 
+// Clone clones url into path, naming the remote after remote
+func Clone(url, path, remote string) (*GitRepo, error) {
+	if remote == "" {
+		remote = "origin"
+	}
+
+	cmd := exec.Command("git", "clone", "--origin", remote, url, path)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("failed to clone %s: %s", url, strings.TrimSpace(string(output)))
+	}
+	return NewGitRepo(path), nil
+}
+
 func (g *GitRepo) IsGitRepo() bool {
 	gitPath := filepath.Join(g.Path, ".git")
 
@@ -124,6 +138,16 @@ func (g *GitRepo) CheckoutBranch(branchName string) error {
 	return nil
 }
 
+// DetachHead leaves the checked out branch without touching the working tree,
+// so the branch can be checked out somewhere else
+func (g *GitRepo) DetachHead() error {
+	cmd := exec.Command("git", "-C", g.Path, "checkout", "--detach")
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to detach HEAD: %w, output: %s", err, string(output))
+	}
+	return nil
+}
+
 func (g *GitRepo) CreateWorktree(path, branchName string) error {
 	// First check if branch exists
 	branchExists, err := g.BranchExists(branchName)
@@ -170,6 +194,25 @@ func (g *GitRepo) RemoveWorktree(path string) error {
 	return nil
 }
 
+// MoveWorktree moves a linked worktree to a new location
+func (g *GitRepo) MoveWorktree(from, to string) error {
+	fromAbs, err := filepath.Abs(from)
+	if err != nil {
+		return fmt.Errorf("failed to get absolute path: %w", err)
+	}
+	toAbs, err := filepath.Abs(to)
+	if err != nil {
+		return fmt.Errorf("failed to get absolute path: %w", err)
+	}
+
+	cmd := exec.Command("git", "-C", g.Path, "worktree", "move", fromAbs, toAbs)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to move worktree: %w, output: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
 func (g *GitRepo) ListWorktrees() ([]string, error) {
 	cmd := exec.Command("git", "-C", g.Path, "worktree", "list", "--porcelain")
 	output, err := cmd.Output()
@@ -248,6 +291,157 @@ func (g *GitRepo) PopStashForContext(contextName string) error {
 	return g.PopStash(stashMessage)
 }
 
+// RetagStashes renames every stash whose message is oldMessage to newMessage,
+// keeping its content. It returns how many stashes were renamed.
+func (g *GitRepo) RetagStashes(oldMessage, newMessage string) (int, error) {
+	if oldMessage == newMessage {
+		return 0, nil
+	}
+
+	count := 0
+	for {
+		stashes, err := g.ListStashes()
+		if err != nil {
+			return count, err
+		}
+
+		index := -1
+		for i, stash := range stashes {
+			if strings.HasSuffix(stash, ": "+oldMessage) {
+				index = i
+				break
+			}
+		}
+		if index < 0 {
+			return count, nil
+		}
+
+		ref := fmt.Sprintf("stash@{%d}", index)
+		hash, err := g.ResolveRef(ref)
+		if err != nil {
+			return count, err
+		}
+
+		cmd := exec.Command("git", "-C", g.Path, "stash", "drop", ref)
+		if output, err := cmd.CombinedOutput(); err != nil {
+			return count, fmt.Errorf("failed to drop stash: %s", strings.TrimSpace(string(output)))
+		}
+
+		// The dropped stash commit still exists, so report it if storing fails
+		cmd = exec.Command("git", "-C", g.Path, "stash", "store", "-m", newMessage, hash)
+		if output, err := cmd.CombinedOutput(); err != nil {
+			return count, fmt.Errorf("failed to store stash %s: %s", hash, strings.TrimSpace(string(output)))
+		}
+		count++
+	}
+}
+
+// CountStashesForContext counts the stashes alfred created for a context
+func (g *GitRepo) CountStashesForContext(contextName string) (int, error) {
+	stashes, err := g.ListStashes()
+	if err != nil {
+		return 0, err
+	}
+
+	suffix := fmt.Sprintf(": alfred-context-%s", contextName)
+	count := 0
+	for _, stash := range stashes {
+		if strings.HasSuffix(stash, suffix) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// CreateStashCommit records the uncommitted changes of tracked files as a
+// commit without touching the working tree. It returns an empty hash when
+// there is nothing to record.
+func (g *GitRepo) CreateStashCommit() (string, error) {
+	cmd := exec.Command("git", "-C", g.Path, "stash", "create")
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to record uncommitted changes: %w", err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// CreateStashCommitWithUntracked records the uncommitted changes, untracked
+// files included, as a stash commit. git stash create cannot record untracked
+// files, so the changes are stashed and restored right away, leaving the
+// working tree and the stash list as they were. It returns an empty hash when
+// there is nothing to record.
+func (g *GitRepo) CreateStashCommitWithUntracked() (string, error) {
+	before, _ := g.ResolveRef("refs/stash")
+
+	cmd := exec.Command("git", "-C", g.Path, "stash", "push", "--include-untracked", "-m", "alfred backup")
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("failed to record uncommitted changes: %s", strings.TrimSpace(string(output)))
+	}
+
+	commit, _ := g.ResolveRef("refs/stash")
+	if commit == "" || commit == before {
+		return "", nil
+	}
+
+	cmd = exec.Command("git", "-C", g.Path, "stash", "pop", "--index")
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return "", fmt.Errorf("failed to restore uncommitted changes, they are kept in stash@{0}: %s", strings.TrimSpace(string(output)))
+	}
+	return commit, nil
+}
+
+// StoreStash adds a stash commit, e.g. from CreateStashCommit, to the stash list
+func (g *GitRepo) StoreStash(commit, message string) error {
+	cmd := exec.Command("git", "-C", g.Path, "stash", "store", "-m", message, commit)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to store stash: %s", strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// DropStash removes the stash holding commit from the stash list
+func (g *GitRepo) DropStash(commit string) error {
+	stashes, err := g.ListStashes()
+	if err != nil {
+		return err
+	}
+
+	for i := range stashes {
+		ref := fmt.Sprintf("stash@{%d}", i)
+		if hash, err := g.ResolveRef(ref); err != nil || hash != commit {
+			continue
+		}
+		cmd := exec.Command("git", "-C", g.Path, "stash", "drop", ref)
+		if output, err := cmd.CombinedOutput(); err != nil {
+			return fmt.Errorf("failed to drop stash: %s", strings.TrimSpace(string(output)))
+		}
+		return nil
+	}
+	return fmt.Errorf("stash %s not found", commit)
+}
+
+// ApplyStash applies a stash commit to the working tree without removing it
+func (g *GitRepo) ApplyStash(commit string) error {
+	cmd := exec.Command("git", "-C", g.Path, "stash", "apply", commit)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to apply stash: %s", strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// UpdateRef points ref at commit, creating it if needed
+func (g *GitRepo) UpdateRef(ref, commit string) error {
+	cmd := exec.Command("git", "-C", g.Path, "update-ref", ref, commit)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to update %s: %s", ref, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
 // HasUpstream checks if the current branch has an upstream configured
 func (g *GitRepo) HasUpstream() (bool, error) {
 	cmd := exec.Command("git", "-C", g.Path, "rev-parse", "--abbrev-ref", "@{upstream}")
@@ -325,8 +519,271 @@ func (g *GitRepo) PushWithUpstream(remote string) error {
 	return nil
 }
 
+// RemoteBranchExists checks whether a branch exists on the given remote
+func (g *GitRepo) RemoteBranchExists(remote, branch string) (bool, error) {
+	if remote == "" {
+		remote = "origin"
+	}
+
+	// ls-remote matches patterns on their last components, so "login" would
+	// also match feature/login without the full ref
+	cmd := exec.Command("git", "-C", g.Path, "ls-remote", remote, "refs/heads/"+branch)
+	output, err := cmd.Output()
+	if err != nil {
+		return false, fmt.Errorf("failed to check remote branch: %w", err)
+	}
+	return len(strings.TrimSpace(string(output))) > 0, nil
+}
+
+// CreateBranchAt creates a branch at startPoint without checking it out
+func (g *GitRepo) CreateBranchAt(branchName, startPoint string) error {
+	cmd := exec.Command("git", "-C", g.Path, "branch", branchName, startPoint)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to create branch '%s' at %s: %s", branchName, startPoint, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// ListLocalBranches returns the names of all local branches
+func (g *GitRepo) ListLocalBranches() ([]string, error) {
+	return g.listRefs("refs/heads/")
+}
+
+// ListRemoteBranches returns the names of the remote-tracking branches of remote,
+// without the remote prefix
+func (g *GitRepo) ListRemoteBranches(remote string) ([]string, error) {
+	if remote == "" {
+		remote = "origin"
+	}
+
+	branches, err := g.listRefs(fmt.Sprintf("refs/remotes/%s/", remote))
+	if err != nil {
+		return nil, err
+	}
+
+	var result []string
+	for _, branch := range branches {
+		if branch != "HEAD" {
+			result = append(result, branch)
+		}
+	}
+	return result, nil
+}
+
+// listRefs returns the refs under prefix with the prefix removed
+func (g *GitRepo) listRefs(prefix string) ([]string, error) {
+	cmd := exec.Command("git", "-C", g.Path, "for-each-ref", "--format=%(refname)", prefix)
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s: %w", prefix, err)
+	}
+
+	var refs []string
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		if line != "" {
+			refs = append(refs, strings.TrimPrefix(line, prefix))
+		}
+	}
+	return refs, nil
+}
+
+// TrackRemoteBranch creates a local branch tracking <remote>/<branch> without checking it out
+func (g *GitRepo) TrackRemoteBranch(branch, remote string) error {
+	if remote == "" {
+		remote = "origin"
+	}
+
+	cmd := exec.Command("git", "-C", g.Path, "branch", "--track", branch, fmt.Sprintf("%s/%s", remote, branch))
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to track %s/%s: %s", remote, branch, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// RenameBranch renames a local branch, including where it is checked out in worktrees
+func (g *GitRepo) RenameBranch(oldName, newName string) error {
+	cmd := exec.Command("git", "-C", g.Path, "branch", "-m", oldName, newName)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to rename branch '%s' to '%s': %s", oldName, newName, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// PushBranch pushes a local branch to the branch of the same name on remote
+func (g *GitRepo) PushBranch(remote, branch string) error {
+	if remote == "" {
+		remote = "origin"
+	}
+
+	cmd := exec.Command("git", "-C", g.Path, "push", remote, fmt.Sprintf("%s:%s", branch, branch))
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to push '%s' to %s: %s", branch, remote, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// SetBranchUpstream sets the upstream of a branch that does not need to be checked out
+func (g *GitRepo) SetBranchUpstream(branch, upstream string) error {
+	cmd := exec.Command("git", "-C", g.Path, "branch", "--set-upstream-to", upstream, branch)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to set upstream of '%s' to %s: %s", branch, upstream, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// DeleteRemoteBranch deletes a branch on the given remote
+func (g *GitRepo) DeleteRemoteBranch(remote, branch string) error {
+	if remote == "" {
+		remote = "origin"
+	}
+
+	cmd := exec.Command("git", "-C", g.Path, "push", remote, "--delete", branch)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to delete remote branch '%s/%s': %s", remote, branch, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// CreateTrackingBranch creates a local branch that tracks <remote>/<branch> and checks it out
+func (g *GitRepo) CreateTrackingBranch(branch, remote string) error {
+	if remote == "" {
+		remote = "origin"
+	}
+
+	fetchCmd := exec.Command("git", "-C", g.Path, "fetch", remote, branch)
+	if output, err := fetchCmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to fetch %s/%s: %s", remote, branch, strings.TrimSpace(string(output)))
+	}
+
+	cmd := exec.Command("git", "-C", g.Path, "checkout", "-b", branch, "--track", fmt.Sprintf("%s/%s", remote, branch))
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to create tracking branch: %s", strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// GetRemoteURL returns the fetch URL of a remote
+func (g *GitRepo) GetRemoteURL(remote string) (string, error) {
+	cmd := exec.Command("git", "-C", g.Path, "remote", "get-url", remote)
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to get url of remote '%s': %w", remote, err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// GetRemoteHead returns the default branch of a remote as recorded by
+// refs/remotes/<remote>/HEAD, which clones set up
+func (g *GitRepo) GetRemoteHead(remote string) (string, error) {
+	cmd := exec.Command("git", "-C", g.Path, "symbolic-ref", "--short", fmt.Sprintf("refs/remotes/%s/HEAD", remote))
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve %s/HEAD: %w", remote, err)
+	}
+	return strings.TrimPrefix(strings.TrimSpace(string(output)), remote+"/"), nil
+}
+
+// BranchTracking describes where a branch pulls from and pushes to
+type BranchTracking struct {
+	Remote     string // branch.<name>.remote
+	Merge      string // branch.<name>.merge, without refs/heads/
+	PushRemote string // branch.<name>.pushRemote
+}
+
+// GetBranchTracking reads the tracking configuration of a local branch
+func (g *GitRepo) GetBranchTracking(branch string) BranchTracking {
+	get := func(key string) string {
+		cmd := exec.Command("git", "-C", g.Path, "config", "--get", fmt.Sprintf("branch.%s.%s", branch, key))
+		output, err := cmd.Output()
+		if err != nil {
+			return ""
+		}
+		return strings.TrimSpace(string(output))
+	}
+
+	return BranchTracking{
+		Remote:     get("remote"),
+		Merge:      strings.TrimPrefix(get("merge"), "refs/heads/"),
+		PushRemote: get("pushRemote"),
+	}
+}
+
+// SetTriangularTracking configures the current branch to pull from
+// <fetchRemote>/<baseBranch> while pushing to pushRemote
+func (g *GitRepo) SetTriangularTracking(fetchRemote, baseBranch, pushRemote string) error {
+	currentBranch, err := g.GetCurrentBranch()
+	if err != nil {
+		return fmt.Errorf("failed to get current branch: %w", err)
+	}
+
+	settings := [][2]string{
+		{fmt.Sprintf("branch.%s.remote", currentBranch), fetchRemote},
+		{fmt.Sprintf("branch.%s.merge", currentBranch), "refs/heads/" + baseBranch},
+		{fmt.Sprintf("branch.%s.pushRemote", currentBranch), pushRemote},
+	}
+
+	for _, setting := range settings {
+		cmd := exec.Command("git", "-C", g.Path, "config", setting[0], setting[1])
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("failed to set %s: %w", setting[0], err)
+		}
+	}
+	return nil
+}
+
+// PushToRemote pushes the current branch to a remote branch of the same name
+// without touching the configured upstream
+func (g *GitRepo) PushToRemote(remote string) error {
+	currentBranch, err := g.GetCurrentBranch()
+	if err != nil {
+		return fmt.Errorf("failed to get current branch: %w", err)
+	}
+
+	cmd := exec.Command("git", "-C", g.Path, "push", remote, currentBranch)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		outputStr := strings.TrimSpace(string(output))
+		if outputStr != "" {
+			return fmt.Errorf("failed to push: %s", outputStr)
+		}
+		return fmt.Errorf("failed to push: %w", err)
+	}
+	return nil
+}
+
+// PullFrom pulls a specific branch from a remote into the current branch
+func (g *GitRepo) PullFrom(remote, branch string, rebase bool) error {
+	args := []string{"-C", g.Path, "pull"}
+	if rebase {
+		// Linked pubspec.yaml files are usually modified, so stash them around the rebase
+		args = append(args, "--rebase", "--autostash")
+	}
+	args = append(args, remote, branch)
+
+	cmd := exec.Command("git", args...)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		outputStr := strings.TrimSpace(string(output))
+		if outputStr != "" {
+			return fmt.Errorf("failed to pull: %s", outputStr)
+		}
+		return fmt.Errorf("failed to pull: %w", err)
+	}
+	return nil
+}
+
 // Pull pulls from upstream, setting it up if needed
-func (g *GitRepo) Pull(rebase bool) error {
+func (g *GitRepo) Pull(remote string, rebase bool) error {
+	if remote == "" {
+		remote = "origin"
+	}
+
 	// Check if upstream is configured
 	hasUpstream, err := g.HasUpstream()
 	if err != nil {
@@ -341,14 +798,13 @@ func (g *GitRepo) Pull(rebase bool) error {
 		}
 
 		// Check if remote branch exists before setting upstream
-		checkCmd := exec.Command("git", "-C", g.Path, "ls-remote", "--heads", "origin", currentBranch)
-		checkOutput, checkErr := checkCmd.Output()
-		if checkErr != nil || len(strings.TrimSpace(string(checkOutput))) == 0 {
-			return fmt.Errorf("remote branch 'origin/%s' does not exist. Push the branch first with 'alfred push'", currentBranch)
+		exists, checkErr := g.RemoteBranchExists(remote, currentBranch)
+		if checkErr != nil || !exists {
+			return fmt.Errorf("remote branch '%s/%s' does not exist. Push the branch first with 'alfred push'", remote, currentBranch)
 		}
 
-		// Try to set upstream to origin/<current-branch>
-		if err := g.SetUpstream("origin", currentBranch); err != nil {
+		// Try to set upstream to <remote>/<current-branch>
+		if err := g.SetUpstream(remote, currentBranch); err != nil {
 			return fmt.Errorf("no upstream configured and failed to set upstream: %w", err)
 		}
 	}
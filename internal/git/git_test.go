@@ -4,6 +4,8 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+
+	"github.com/viniciusamelio/alfred/internal/testutil"
 )
 
 // findGitRoot walks up the directory tree to find the git repository root
@@ -95,3 +97,127 @@ func TestGitRepo_IsGitRepo(t *testing.T) {
 		t.Error("Expected temp directory to not be a git repository")
 	}
 }
+
+// setupRemote creates a bare repository acting as a remote, with a main branch
+// and a feature/login branch pushed by a teammate, and a clone of it that has
+// not fetched the feature branch yet
+func setupRemote(t *testing.T) (string, *GitRepo) {
+	t.Helper()
+
+	testutil.SetGitIdentity(t)
+
+	root := t.TempDir()
+	remote := testutil.CreateRemote(t, root, "remote", "main", nil)
+	teammate := filepath.Join(root, "teammate")
+	local := filepath.Join(root, "local")
+
+	testutil.RunGit(t, root, "clone", "-q", remote, teammate)
+	testutil.RunGit(t, root, "clone", "-q", remote, local)
+
+	testutil.RunGit(t, teammate, "checkout", "-q", "-b", "feature/login")
+	testutil.RunGit(t, teammate, "commit", "-q", "--allow-empty", "-m", "login")
+	testutil.RunGit(t, teammate, "push", "-q", "origin", "feature/login")
+
+	return remote, NewGitRepo(local)
+}
+
+func TestGitRepo_TrackRemoteBranch(t *testing.T) {
+	_, repo := setupRemote(t)
+
+	exists, err := repo.RemoteBranchExists("origin", "feature/login")
+	if err != nil {
+		t.Fatalf("RemoteBranchExists failed: %v", err)
+	}
+	if !exists {
+		t.Fatal("Expected feature/login to exist on the remote")
+	}
+	if exists, err := repo.RemoteBranchExists("origin", "login"); err != nil || exists {
+		t.Errorf("Expected login not to match feature/login, got %v (%v)", exists, err)
+	}
+
+	branches, err := repo.ListRemoteBranches("origin")
+	if err != nil {
+		t.Fatalf("ListRemoteBranches failed: %v", err)
+	}
+	if len(branches) != 1 || branches[0] != "main" {
+		t.Errorf("Expected only main before fetching, got %v", branches)
+	}
+
+	if err := repo.Fetch("origin"); err != nil {
+		t.Fatalf("Fetch failed: %v", err)
+	}
+
+	branches, err = repo.ListRemoteBranches("origin")
+	if err != nil {
+		t.Fatalf("ListRemoteBranches failed: %v", err)
+	}
+	if len(branches) != 2 {
+		t.Errorf("Expected main and feature/login after fetching, got %v", branches)
+	}
+
+	if err := repo.TrackRemoteBranch("feature/login", "origin"); err != nil {
+		t.Fatalf("TrackRemoteBranch failed: %v", err)
+	}
+
+	current, err := repo.GetCurrentBranch()
+	if err != nil {
+		t.Fatalf("Failed to get current branch: %v", err)
+	}
+	if current != "main" {
+		t.Errorf("Tracking a branch should not check it out, current branch is %s", current)
+	}
+
+	tracking := repo.GetBranchTracking("feature/login")
+	if tracking.Remote != "origin" || tracking.Merge != "feature/login" {
+		t.Errorf("Unexpected tracking configuration: %+v", tracking)
+	}
+
+	ahead, behind, err := repo.GetAheadBehind("feature/login", "origin/feature/login")
+	if err != nil {
+		t.Fatalf("GetAheadBehind failed: %v", err)
+	}
+	if ahead != 0 || behind != 0 {
+		t.Errorf("Expected tracking branch to match the remote, got ahead %d behind %d", ahead, behind)
+	}
+}
+
+func TestGitRepo_DeleteRemoteBranch(t *testing.T) {
+	_, repo := setupRemote(t)
+
+	if err := repo.DeleteRemoteBranch("origin", "feature/login"); err != nil {
+		t.Fatalf("DeleteRemoteBranch failed: %v", err)
+	}
+
+	exists, err := repo.RemoteBranchExists("origin", "feature/login")
+	if err != nil {
+		t.Fatalf("RemoteBranchExists failed: %v", err)
+	}
+	if exists {
+		t.Error("Expected feature/login to be deleted from the remote")
+	}
+}
+
+func TestClone_FileURL(t *testing.T) {
+	remote, _ := setupRemote(t)
+	path := filepath.Join(t.TempDir(), "nested", "clone")
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	repo, err := Clone("file://"+remote, path, "upstream")
+	if err != nil {
+		t.Fatalf("Clone failed: %v", err)
+	}
+	if !repo.IsGitRepo() {
+		t.Fatal("Expected the clone to be a git repository")
+	}
+
+	if url, err := repo.GetRemoteURL("upstream"); err != nil || url != "file://"+remote {
+		t.Errorf("Expected remote upstream to point to the bare repository, got %q (%v)", url, err)
+	}
+
+	if _, err := Clone("file://"+remote, path, "upstream"); err == nil {
+		t.Error("Expected cloning into an existing repository to fail")
+	}
+}
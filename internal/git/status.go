@@ -0,0 +1,192 @@
+package git
+
+import (
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CommitInfo describes a single commit
+type CommitInfo struct {
+	Hash    string
+	Subject string
+	Date    time.Time
+}
+
+// ChangeCounts summarizes the working tree state
+type ChangeCounts struct {
+	Staged    int
+	Unstaged  int
+	Untracked int
+}
+
+// IsDirty reports whether there is anything to commit or stash
+func (c ChangeCounts) IsDirty() bool {
+	return c.Staged > 0 || c.Unstaged > 0 || c.Untracked > 0
+}
+
+// GetUpstream returns the upstream of the current branch, e.g. origin/main
+func (g *GitRepo) GetUpstream() (string, error) {
+	cmd := exec.Command("git", "-C", g.Path, "rev-parse", "--abbrev-ref", "@{upstream}")
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("no upstream configured: %w", err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// GetAheadBehind counts the commits of from that are not in to (ahead) and the
+// commits of to that are not in from (behind)
+func (g *GitRepo) GetAheadBehind(from, to string) (int, int, error) {
+	cmd := exec.Command("git", "-C", g.Path, "rev-list", "--left-right", "--count", fmt.Sprintf("%s...%s", from, to))
+	output, err := cmd.Output()
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to compare %s with %s: %w", from, to, err)
+	}
+
+	fields := strings.Fields(string(output))
+	if len(fields) != 2 {
+		return 0, 0, fmt.Errorf("unexpected rev-list output: %q", string(output))
+	}
+
+	ahead, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to parse ahead count: %w", err)
+	}
+	behind, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to parse behind count: %w", err)
+	}
+
+	return ahead, behind, nil
+}
+
+// GetChangeCounts counts staged, unstaged and untracked files
+func (g *GitRepo) GetChangeCounts() (ChangeCounts, error) {
+	var counts ChangeCounts
+
+	cmd := exec.Command("git", "-C", g.Path, "status", "--porcelain")
+	output, err := cmd.Output()
+	if err != nil {
+		return counts, fmt.Errorf("failed to get git status: %w", err)
+	}
+
+	for _, line := range strings.Split(string(output), "\n") {
+		if len(line) < 2 {
+			continue
+		}
+
+		// Git status format: XY filename (X = staged, Y = unstaged)
+		if line[0] == '?' && line[1] == '?' {
+			counts.Untracked++
+			continue
+		}
+		if line[0] != ' ' {
+			counts.Staged++
+		}
+		if line[1] != ' ' {
+			counts.Unstaged++
+		}
+	}
+
+	return counts, nil
+}
+
+// GetChangedFiles lists the paths with staged, unstaged or untracked changes
+func (g *GitRepo) GetChangedFiles() ([]string, error) {
+	cmd := exec.Command("git", "-C", g.Path, "status", "--porcelain", "--untracked-files=all")
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get git status: %w", err)
+	}
+
+	var files []string
+	for _, line := range strings.Split(string(output), "\n") {
+		if len(line) < 4 {
+			continue
+		}
+		files = append(files, line[3:])
+	}
+	return files, nil
+}
+
+// GetLastCommit returns the commit ref points to
+func (g *GitRepo) GetLastCommit(ref string) (*CommitInfo, error) {
+	if ref == "" {
+		ref = "HEAD"
+	}
+
+	cmd := exec.Command("git", "-C", g.Path, "log", "-1", "--format=%h%x00%s%x00%ct", ref, "--")
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get last commit: %w", err)
+	}
+
+	parts := strings.SplitN(strings.TrimSpace(string(output)), "\x00", 3)
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("no commits found on %s", ref)
+	}
+
+	timestamp, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse commit date: %w", err)
+	}
+
+	return &CommitInfo{
+		Hash:    parts[0],
+		Subject: parts[1],
+		Date:    time.Unix(timestamp, 0),
+	}, nil
+}
+
+// IsMergedInto checks whether every commit of branch is reachable from target
+func (g *GitRepo) IsMergedInto(branch, target string) (bool, error) {
+	cmd := exec.Command("git", "-C", g.Path, "merge-base", "--is-ancestor", branch, target)
+	err := cmd.Run()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 1 {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to check if %s is merged into %s: %w", branch, target, err)
+	}
+	return true, nil
+}
+
+// IsOnFirstParentHistory reports whether the tip of branch is on the
+// first-parent history of target, as for a branch created from target without
+// commits of its own, or fast-forwarded into it
+func (g *GitRepo) IsOnFirstParentHistory(branch, target string) (bool, error) {
+	tip, err := g.ResolveRef(branch)
+	if err != nil {
+		return false, err
+	}
+
+	cmd := exec.Command("git", "-C", g.Path, "rev-list", "--first-parent", target)
+	output, err := cmd.Output()
+	if err != nil {
+		return false, fmt.Errorf("failed to list the history of %s: %w", target, err)
+	}
+	for _, commit := range strings.Split(string(output), "\n") {
+		if commit == tip {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// CountUnpushedCommits counts the commits of branch that are not on any remote
+func (g *GitRepo) CountUnpushedCommits(branch string) (int, error) {
+	cmd := exec.Command("git", "-C", g.Path, "rev-list", "--count", branch, "--not", "--remotes")
+	output, err := cmd.Output()
+	if err != nil {
+		return 0, fmt.Errorf("failed to count unpushed commits: %w", err)
+	}
+
+	count, err := strconv.Atoi(strings.TrimSpace(string(output)))
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse unpushed commit count: %w", err)
+	}
+	return count, nil
+}
@@ -0,0 +1,154 @@
+package git
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Fetch fetches all branches from a remote
+func (g *GitRepo) Fetch(remote string) error {
+	if remote == "" {
+		remote = "origin"
+	}
+
+	cmd := exec.Command("git", "-C", g.Path, "fetch", remote)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to fetch %s: %s", remote, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// GetHeadCommit returns the full hash of the current HEAD
+func (g *GitRepo) GetHeadCommit() (string, error) {
+	return g.ResolveRef("HEAD")
+}
+
+// ResolveRef returns the commit hash a ref points to
+func (g *GitRepo) ResolveRef(ref string) (string, error) {
+	cmd := exec.Command("git", "-C", g.Path, "rev-parse", "--verify", "--quiet", ref+"^{commit}")
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve %s: %w", ref, err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// Rebase rebases the current branch onto the given ref, stashing local changes around it
+func (g *GitRepo) Rebase(onto string) error {
+	cmd := exec.Command("git", "-C", g.Path, "rebase", "--autostash", onto)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to rebase onto %s: %s", onto, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// Merge merges the given ref into the current branch, stashing local changes around it
+func (g *GitRepo) Merge(ref string) error {
+	cmd := exec.Command("git", "-C", g.Path, "merge", "--no-edit", "--autostash", ref)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to merge %s: %s", ref, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// ContinueRebase continues an in-progress rebase without opening an editor
+func (g *GitRepo) ContinueRebase() error {
+	cmd := exec.Command("git", "-C", g.Path, "-c", "core.editor=true", "rebase", "--continue")
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to continue rebase: %s", strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// AbortRebase aborts an in-progress rebase
+func (g *GitRepo) AbortRebase() error {
+	cmd := exec.Command("git", "-C", g.Path, "rebase", "--abort")
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to abort rebase: %s", strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// ContinueMerge concludes an in-progress merge using the prepared message
+func (g *GitRepo) ContinueMerge() error {
+	cmd := exec.Command("git", "-C", g.Path, "commit", "--no-edit")
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to continue merge: %s", strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// AbortMerge aborts an in-progress merge
+func (g *GitRepo) AbortMerge() error {
+	cmd := exec.Command("git", "-C", g.Path, "merge", "--abort")
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to abort merge: %s", strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// ResetKeep moves the current branch back to a commit while keeping local changes
+func (g *GitRepo) ResetKeep(commit string) error {
+	cmd := exec.Command("git", "-C", g.Path, "reset", "--keep", commit)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to reset to %s: %s", commit, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// IsRebaseInProgress checks whether a rebase is waiting to be continued or aborted
+func (g *GitRepo) IsRebaseInProgress() bool {
+	gitDir, err := g.GetGitDir()
+	if err != nil {
+		return false
+	}
+
+	for _, dir := range []string{"rebase-merge", "rebase-apply"} {
+		if _, err := os.Stat(filepath.Join(gitDir, dir)); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// IsMergeInProgress checks whether a merge is waiting to be concluded or aborted
+func (g *GitRepo) IsMergeInProgress() bool {
+	gitDir, err := g.GetGitDir()
+	if err != nil {
+		return false
+	}
+
+	_, err = os.Stat(filepath.Join(gitDir, "MERGE_HEAD"))
+	return err == nil
+}
+
+// HasConflicts checks whether there are unmerged paths in the working tree
+func (g *GitRepo) HasConflicts() (bool, error) {
+	cmd := exec.Command("git", "-C", g.Path, "diff", "--name-only", "--diff-filter=U")
+	output, err := cmd.Output()
+	if err != nil {
+		return false, fmt.Errorf("failed to check conflicts: %w", err)
+	}
+	return len(strings.TrimSpace(string(output))) > 0, nil
+}
+
+// GetGitDir returns the absolute git directory, which differs per worktree
+func (g *GitRepo) GetGitDir() (string, error) {
+	cmd := exec.Command("git", "-C", g.Path, "rev-parse", "--absolute-git-dir")
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to get git directory: %w", err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
@@ -4,8 +4,12 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
 	"regexp"
+	"sort"
 	"strings"
+
+	"gopkg.in/yaml.v3"
 )
 
 type PubspecYaml struct {
@@ -131,6 +135,23 @@ func (p *PubspecYaml) GetGitDependencies() map[string]*GitDependency {
 	return gitDeps
 }
 
+// GetDependencyNames returns the names of the packages listed under dependencies
+func (p *PubspecYaml) GetDependencyNames() ([]string, error) {
+	var parsed struct {
+		Dependencies map[string]interface{} `yaml:"dependencies"`
+	}
+	if err := yaml.Unmarshal([]byte(p.content), &parsed); err != nil {
+		return nil, fmt.Errorf("failed to parse pubspec.yaml: %w", err)
+	}
+
+	var names []string
+	for name := range parsed.Dependencies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
 func ExtractRepoNameFromGitURL(gitURL string) string {
 	re := regexp.MustCompile(`([^/]+?)(?:\.git)?$`)
 	matches := re.FindStringSubmatch(gitURL)
@@ -174,6 +195,27 @@ func (p *PubspecYaml) UpdatePathDependency(depName, newPath string) error {
 	return nil
 }
 
+const (
+	DependencyPath = "path"
+	DependencyGit  = "git"
+)
+
+// GetDependencySource reports whether a dependency is a local path dependency,
+// a git dependency, or something else (empty string, e.g. hosted or missing)
+func (p *PubspecYaml) GetDependencySource(depName string) string {
+	pathPattern := regexp.MustCompile(`(?m)^(\s*)` + regexp.QuoteMeta(depName) + `:\s*\n(\s+)path:`)
+	if pathPattern.MatchString(p.content) {
+		return DependencyPath
+	}
+
+	gitPattern := regexp.MustCompile(`(?m)^(\s*)` + regexp.QuoteMeta(depName) + `:\s*\n((?:\s*#.*\n)*)(\s+)git:`)
+	if gitPattern.MatchString(p.content) {
+		return DependencyGit
+	}
+
+	return ""
+}
+
 // GetPackageName extracts the package name from pubspec.yaml content
 func (p *PubspecYaml) GetPackageName() (string, error) {
 	// Pattern to find the name field
@@ -204,31 +246,29 @@ func ExtractPackageNameFromFile(pubspecPath string) (string, error) {
 func (p *PubspecYaml) CommentGitDependencyAndAddPath(depName, localPath string) error {
 	// Pattern to find git dependency block for the specified dependency
 	// Updated to handle optional commented lines between dependency name and git block
-	gitPattern := regexp.MustCompile(`(?m)^(\s*)` + regexp.QuoteMeta(depName) + `:\s*\n((?:\s*#.*\n)*)(\s+)git:\s*\n(\s+url:.*\n)(\s+ref:.*\n)?`)
+	gitPattern := regexp.MustCompile(`(?m)^([ \t]*)` + regexp.QuoteMeta(depName) + `:\s*\n((?:\s*#.*\n)*)(\s+)git:\s*\n(\s+url:.*\n)(\s+ref:.*\n)?`)
 
 	if !gitPattern.MatchString(p.content) {
 		return fmt.Errorf("dependency '%s' is not a git dependency", depName)
 	}
 
-	// Replace git dependency with commented git + new path
+	// Replace git dependency with a path dependency followed by the commented original block
 	replacement := func(match string) string {
+		indent := gitPattern.FindStringSubmatch(match)[1]
 		lines := strings.Split(strings.TrimSuffix(match, "\n"), "\n")
 		var result strings.Builder
 
-		// Add path dependency first
-		result.WriteString(fmt.Sprintf("%s:\n", depName))
-		result.WriteString(fmt.Sprintf("    path: %s\n", localPath))
+		result.WriteString(fmt.Sprintf("%s%s:\n", indent, depName))
+		result.WriteString(fmt.Sprintf("%s  path: %s\n", indent, localPath))
 
-		// Comment out the original git dependency (skip the first line which is the dependency name)
-		for i, line := range lines {
-			if i == 0 {
-				continue // Skip the dependency name line as we already added it
-			}
-			if strings.TrimSpace(line) != "" {
-				result.WriteString("  # ")
-				result.WriteString(line)
-				result.WriteString("\n")
+		for _, line := range lines {
+			if strings.TrimSpace(line) == "" {
+				continue
 			}
+			result.WriteString(indent)
+			result.WriteString("# ")
+			result.WriteString(strings.TrimPrefix(line, indent))
+			result.WriteString("\n")
 		}
 
 		return result.String()
@@ -240,33 +280,80 @@ func (p *PubspecYaml) CommentGitDependencyAndAddPath(depName, localPath string)
 
 // UncommentGitDependencyAndRemovePath uncomments git dependency and removes path dependency
 func (p *PubspecYaml) UncommentGitDependencyAndRemovePath(depName string) error {
-	// Pattern to find the path dependency followed by commented git dependency
-	pathAndCommentedGitPattern := regexp.MustCompile(`(?ms)^(\s*)` + regexp.QuoteMeta(depName) + `:\s*\n\s*path:.*\n(\s*#\s*` + regexp.QuoteMeta(depName) + `:\s*\n\s*#\s*git:\s*\n(\s*#\s*url:.*\n)(\s*#\s*ref:.*\n)?)`)
+	// Pattern to find the path dependency followed by the commented git dependency block
+	pathAndCommentedGitPattern := regexp.MustCompile(`(?m)^([ \t]*)` + regexp.QuoteMeta(depName) + `:[ \t]*\n[ \t]+path:.*\n((?:[ \t]*#.*\n)+)`)
 
 	match := pathAndCommentedGitPattern.FindStringSubmatch(p.content)
 	if len(match) == 0 {
 		return fmt.Errorf("dependency '%s' pattern not found", depName)
 	}
 
-	// Build the restored git dependency
-	var gitDep strings.Builder
-	gitDep.WriteString(fmt.Sprintf("%s:\n", depName))
-	gitDep.WriteString("  git:\n")
-
-	// Extract and restore URL line
-	urlLine := match[3] // url line with # prefix
-	// Remove leading # and whitespace, but preserve the actual content
-	cleanUrl := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(urlLine), "#"))
-	gitDep.WriteString(fmt.Sprintf("    %s\n", cleanUrl))
-
-	// Extract and restore ref line if exists
-	if len(match) > 4 && match[4] != "" {
-		refLine := match[4] // ref line with # prefix
-		cleanRef := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(refLine), "#"))
-		gitDep.WriteString(fmt.Sprintf("    %s\n", cleanRef))
+	indent := match[1]
+	commented := strings.Split(strings.TrimSuffix(match[2], "\n"), "\n")
+	if len(commented) == 0 || strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(commented[0]), "#")) != depName+":" {
+		return fmt.Errorf("dependency '%s' has no commented git block", depName)
+	}
+
+	// Restore the block; commented lines after it are unrelated and kept as they are
+	var restored, unrelated strings.Builder
+	inBlock := true
+	for i, line := range commented {
+		uncommented := strings.TrimPrefix(strings.TrimPrefix(line, indent), "# ")
+		if inBlock && (i == 0 || strings.HasPrefix(uncommented, " ")) {
+			restored.WriteString(indent + uncommented + "\n")
+			continue
+		}
+		inBlock = false
+		unrelated.WriteString(line + "\n")
 	}
 
-	// Replace the entire block with just the git dependency
-	p.content = pathAndCommentedGitPattern.ReplaceAllString(p.content, gitDep.String())
+	loc := pathAndCommentedGitPattern.FindStringIndex(p.content)
+	p.content = p.content[:loc[0]] + restored.String() + unrelated.String() + p.content[loc[1]:]
 	return nil
 }
+
+// dependencySections are the pubspec sections alfred links dependencies in
+var dependencySections = []string{"dependencies", "dev_dependencies", "dependency_overrides"}
+
+// IsLinkRewrite reports whether current differs from committed only by the
+// changes alfred makes when linking a context: git dependencies commented out
+// in favor of path dependencies, and path dependencies pointing elsewhere.
+// Any other difference is an edit of the user.
+func IsLinkRewrite(committed, current string) bool {
+	restored := &PubspecYaml{content: current}
+	var parsed map[string]interface{}
+	if err := yaml.Unmarshal([]byte(current), &parsed); err != nil {
+		return false
+	}
+	for _, section := range dependencySections {
+		deps, _ := parsed[section].(map[string]interface{})
+		for name := range deps {
+			// Dependencies without a commented git block are left as they are
+			_ = restored.UncommentGitDependencyAndRemovePath(name)
+		}
+	}
+
+	a, errA := withoutPathValues(committed)
+	b, errB := withoutPathValues(restored.content)
+	return errA == nil && errB == nil && reflect.DeepEqual(a, b)
+}
+
+// withoutPathValues parses a pubspec and blanks the path of its path
+// dependencies, which alfred points at the checkouts of the context
+func withoutPathValues(content string) (map[string]interface{}, error) {
+	var parsed map[string]interface{}
+	if err := yaml.Unmarshal([]byte(content), &parsed); err != nil {
+		return nil, err
+	}
+	for _, section := range dependencySections {
+		deps, _ := parsed[section].(map[string]interface{})
+		for _, dep := range deps {
+			if source, ok := dep.(map[string]interface{}); ok {
+				if _, isPath := source["path"]; isPath {
+					source["path"] = ""
+				}
+			}
+		}
+	}
+	return parsed, nil
+}
@@ -0,0 +1,71 @@
+package pubspec
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPubspecYaml_LinkRoundTrip(t *testing.T) {
+	original := `name: app
+dependencies:
+  core:
+    git:
+      url: git@example.com:core.git
+      ref: main
+  ui:
+    git:
+      url: git@example.com:ui.git
+      ref: main
+`
+
+	p := &PubspecYaml{content: original}
+
+	if err := p.CommentGitDependencyAndAddPath("core", "../core-feat"); err != nil {
+		t.Fatalf("CommentGitDependencyAndAddPath failed: %v", err)
+	}
+
+	if got := p.GetDependencySource("core"); got != DependencyPath {
+		t.Errorf("core source = %q after linking, expected %q", got, DependencyPath)
+	}
+	if got := p.GetDependencySource("ui"); got != DependencyGit {
+		t.Errorf("ui source = %q after linking core, expected %q", got, DependencyGit)
+	}
+
+	if err := p.UncommentGitDependencyAndRemovePath("core"); err != nil {
+		t.Fatalf("UncommentGitDependencyAndRemovePath failed: %v", err)
+	}
+
+	if p.content != original {
+		t.Errorf("unlinking did not restore the original pubspec:\n%s", p.content)
+	}
+}
+
+func TestIsLinkRewrite(t *testing.T) {
+	committed := `name: app
+dependencies:
+  core:
+    git:
+      url: git@example.com:core.git
+      ref: main
+  ui:
+    path: ../ui
+`
+
+	p := &PubspecYaml{content: committed}
+	if err := p.CommentGitDependencyAndAddPath("core", "../core-feat"); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.UpdatePathDependency("ui", "../ui-feat"); err != nil {
+		t.Fatal(err)
+	}
+	if !IsLinkRewrite(committed, p.content) {
+		t.Errorf("Expected the linked pubspec to be a rewrite of alfred:\n%s", p.content)
+	}
+
+	if IsLinkRewrite(committed, p.content+"  http: ^1.0.0\n") {
+		t.Error("Expected an added dependency to be an edit of the user")
+	}
+	if IsLinkRewrite(committed, strings.Replace(p.content, "ref: main", "ref: v2", 1)) {
+		t.Error("Expected a changed git ref to be an edit of the user")
+	}
+}
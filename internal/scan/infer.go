@@ -0,0 +1,138 @@
+package scan
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/viniciusamelio/alfred/internal/git"
+	"github.com/viniciusamelio/alfred/internal/pubspec"
+)
+
+// DefaultRemote is the remote main branches and URLs are read from
+const DefaultRemote = "origin"
+
+// RepoInfo holds what could be inferred about a scanned package
+type RepoInfo struct {
+	Package
+	MainBranch  string // default branch of the remote, empty if unknown
+	URL         string // url of the remote, empty if there is none
+	SiblingDeps int    // dependencies on other scanned packages
+	HasMain     bool   // whether lib/main.dart exists
+}
+
+// Inference is the configuration proposed for the scanned packages
+type Inference struct {
+	Repos []RepoInfo
+	// Master is the index of the proposed master repository, -1 if no package
+	// stands out
+	Master       int
+	MasterReason string
+	// MainBranch is the main branch shared by most repositories
+	MainBranch string
+}
+
+// Infer proposes the master repository, the main branches and the remote URLs
+// of the scanned packages, reading their pubspecs and git repositories under root.
+// The master is the package depending on the most other packages, or the
+// Flutter app with a lib/main.dart.
+func Infer(root string, packages []Package) *Inference {
+	names := make(map[string]bool)
+	for _, pkg := range packages {
+		names[pkg.Name] = true
+	}
+
+	inference := &Inference{Master: -1}
+	var branches []string
+
+	for _, pkg := range packages {
+		dir := filepath.Join(root, pkg.Path)
+		info := RepoInfo{Package: pkg}
+
+		if pubspecFile, err := pubspec.LoadPubspec(dir); err == nil {
+			if deps, err := pubspecFile.GetDependencyNames(); err == nil {
+				for _, dep := range deps {
+					if dep != pkg.Name && names[dep] {
+						info.SiblingDeps++
+					}
+				}
+			}
+		}
+
+		if _, err := os.Stat(filepath.Join(dir, "lib", "main.dart")); err == nil {
+			info.HasMain = true
+		}
+
+		if pkg.Repo != "" {
+			gitRepo := git.NewGitRepo(filepath.Join(root, pkg.Repo))
+			info.MainBranch = inferMainBranch(gitRepo)
+			if url, err := gitRepo.GetRemoteURL(DefaultRemote); err == nil {
+				info.URL = url
+			}
+		}
+		branches = append(branches, info.MainBranch)
+
+		inference.Repos = append(inference.Repos, info)
+	}
+
+	inference.Master, inference.MasterReason = inferMaster(inference.Repos)
+
+	inference.MainBranch = MostCommonBranch(branches)
+	return inference
+}
+
+// MostCommonBranch returns the branch used by most repositories, ignoring
+// unknown ones, or main when none is known
+func MostCommonBranch(branches []string) string {
+	counts := make(map[string]int)
+	best := ""
+	for _, branch := range branches {
+		if branch == "" {
+			continue
+		}
+		counts[branch]++
+		if best == "" || counts[branch] > counts[best] || (counts[branch] == counts[best] && branch < best) {
+			best = branch
+		}
+	}
+	if best == "" {
+		return "main"
+	}
+	return best
+}
+
+// inferMainBranch reads the default branch from origin/HEAD, falling back to a
+// local main or master branch
+func inferMainBranch(gitRepo *git.GitRepo) string {
+	if branch, err := gitRepo.GetRemoteHead(DefaultRemote); err == nil && branch != "" {
+		return branch
+	}
+	for _, branch := range []string{"main", "master"} {
+		if exists, err := gitRepo.BranchExists(branch); err == nil && exists {
+			return branch
+		}
+	}
+	return ""
+}
+
+func inferMaster(repos []RepoInfo) (int, string) {
+	master := -1
+	for i, repo := range repos {
+		if repo.SiblingDeps == 0 {
+			continue
+		}
+		if master < 0 || repo.SiblingDeps > repos[master].SiblingDeps ||
+			(repo.SiblingDeps == repos[master].SiblingDeps && repo.HasMain && !repos[master].HasMain) {
+			master = i
+		}
+	}
+	if master >= 0 {
+		return master, "depends on the most other packages"
+	}
+
+	for i, repo := range repos {
+		if repo.HasMain {
+			return i, "has lib/main.dart"
+		}
+	}
+	return -1, ""
+}
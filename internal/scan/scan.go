@@ -0,0 +1,212 @@
+package scan
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/viniciusamelio/alfred/internal/pubspec"
+)
+
+// DefaultIgnore lists directories that never contain packages worth registering
+var DefaultIgnore = []string{"build", "node_modules"}
+
+// Options controls how deep and where a workspace is scanned
+type Options struct {
+	// Depth is how many directory levels below the root are searched
+	Depth int
+	// Ignore holds globs matched against the slash-separated path relative to
+	// the root and against the directory name
+	Ignore []string
+	// WorktreeSuffixes are the directory suffixes of alfred worktrees, such as
+	// "-feature-1", used to skip worktrees that are not linked to git anymore
+	WorktreeSuffixes []string
+}
+
+// Package is a Dart/Flutter package found while scanning
+type Package struct {
+	Name string
+	Path string // relative to the root, starting with ./
+	// Repo is the path of the git repository holding the package, empty when
+	// the package is not inside a repository of its own
+	Repo string
+	// Nested lists the other packages found inside the same git repository
+	Nested []string
+}
+
+// Scan searches root for Dart/Flutter packages. Hidden directories, ignored
+// directories and alfred worktrees are skipped. When a git repository holds
+// several packages, only the one at its root, or the shallowest one, is
+// returned, with the others listed in Nested.
+func Scan(root string, opts Options) ([]Package, []string, error) {
+	if opts.Depth < 1 {
+		opts.Depth = 1
+	}
+
+	s := &scanner{root: root, opts: opts}
+	if err := s.walk(root, 0, ""); err != nil {
+		return nil, s.warnings, err
+	}
+
+	return groupByRepo(s.found), s.warnings, nil
+}
+
+type scanner struct {
+	root     string
+	opts     Options
+	found    []Package
+	warnings []string
+}
+
+func (s *scanner) walk(dir string, depth int, repo string) error {
+	if depth > 0 {
+		rel := s.relative(dir)
+
+		switch gitKind(dir) {
+		case gitWorktree:
+			return nil
+		case gitRepository:
+			repo = rel
+		}
+
+		if _, err := os.Stat(filepath.Join(dir, "pubspec.yaml")); err == nil {
+			name, err := pubspec.ExtractPackageNameFromFile(filepath.Join(dir, "pubspec.yaml"))
+			if err != nil {
+				s.warnings = append(s.warnings, fmt.Sprintf("could not read package name from %s: %v", rel, err))
+			} else {
+				s.found = append(s.found, Package{Name: name, Path: rel, Repo: repo})
+			}
+		}
+	}
+
+	if depth >= s.opts.Depth {
+		return nil
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if depth == 0 {
+			return fmt.Errorf("failed to read %s: %w", dir, err)
+		}
+		s.warnings = append(s.warnings, fmt.Sprintf("could not read %s: %v", s.relative(dir), err))
+		return nil
+	}
+
+	for _, entry := range entries {
+		if !entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+
+		path := filepath.Join(dir, entry.Name())
+		if s.ignored(path, entry.Name()) || s.isStaleWorktree(dir, entry.Name()) {
+			continue
+		}
+
+		if err := s.walk(path, depth+1, repo); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// relative returns path relative to the scan root, in the ./dir/sub form used in alfred.yaml
+func (s *scanner) relative(path string) string {
+	rel, err := filepath.Rel(s.root, path)
+	if err != nil {
+		rel = path
+	}
+	return "./" + filepath.ToSlash(rel)
+}
+
+func (s *scanner) ignored(path, name string) bool {
+	rel := strings.TrimPrefix(s.relative(path), "./")
+	for _, pattern := range append(append([]string(nil), DefaultIgnore...), s.opts.Ignore...) {
+		pattern = strings.TrimSuffix(strings.TrimPrefix(pattern, "./"), "/")
+		if matched, _ := filepath.Match(pattern, rel); matched {
+			return true
+		}
+		if matched, _ := filepath.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// isStaleWorktree reports whether name looks like an alfred worktree of a
+// sibling repository, e.g. core-feature-1 next to core
+func (s *scanner) isStaleWorktree(dir, name string) bool {
+	for _, suffix := range s.opts.WorktreeSuffixes {
+		base := strings.TrimSuffix(name, suffix)
+		if base == name || base == "" {
+			continue
+		}
+		if info, err := os.Stat(filepath.Join(dir, base)); err == nil && info.IsDir() {
+			return true
+		}
+	}
+	return false
+}
+
+const (
+	gitNone = iota
+	gitRepository
+	gitWorktree
+)
+
+// gitKind tells whether dir is the root of a git repository or of a linked worktree
+func gitKind(dir string) int {
+	info, err := os.Stat(filepath.Join(dir, ".git"))
+	if err != nil {
+		return gitNone
+	}
+	if info.IsDir() {
+		return gitRepository
+	}
+
+	// Linked worktrees have a .git file pointing to <repo>/.git/worktrees/<name>,
+	// submodules one pointing to <repo>/.git/modules/<name>
+	data, err := os.ReadFile(filepath.Join(dir, ".git"))
+	if err == nil && strings.Contains(filepath.ToSlash(string(data)), "/worktrees/") {
+		return gitWorktree
+	}
+	return gitRepository
+}
+
+// groupByRepo keeps one package per git repository
+func groupByRepo(found []Package) []Package {
+	var packages []Package
+	byRepo := make(map[string]int)
+
+	for _, pkg := range found {
+		if pkg.Repo == "" {
+			packages = append(packages, pkg)
+			continue
+		}
+
+		i, ok := byRepo[pkg.Repo]
+		if !ok {
+			byRepo[pkg.Repo] = len(packages)
+			packages = append(packages, pkg)
+			continue
+		}
+
+		primary := &packages[i]
+		if depthOf(pkg.Path) < depthOf(primary.Path) {
+			pkg.Nested = append(primary.Nested, primary.Path)
+			*primary = pkg
+		} else {
+			primary.Nested = append(primary.Nested, pkg.Path)
+		}
+	}
+
+	for i := range packages {
+		sort.Strings(packages[i].Nested)
+	}
+	return packages
+}
+
+func depthOf(path string) int {
+	return strings.Count(strings.TrimPrefix(path, "./"), "/")
+}
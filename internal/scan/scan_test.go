@@ -0,0 +1,161 @@
+package scan
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/viniciusamelio/alfred/internal/testutil"
+)
+
+func writePubspec(t *testing.T, dir, name string) {
+	t.Helper()
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "pubspec.yaml"), []byte("name: "+name+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func markGit(t *testing.T, dir, content string) {
+	t.Helper()
+
+	var err error
+	if content == "" {
+		err = os.MkdirAll(filepath.Join(dir, ".git"), 0755)
+	} else {
+		err = os.WriteFile(filepath.Join(dir, ".git"), []byte(content), 0644)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestScan(t *testing.T) {
+	root := t.TempDir()
+
+	// A repository with an example app
+	writePubspec(t, filepath.Join(root, "core"), "core")
+	writePubspec(t, filepath.Join(root, "core", "example"), "core_example")
+	markGit(t, filepath.Join(root, "core"), "")
+
+	// A monorepo without a root package
+	writePubspec(t, filepath.Join(root, "packages", "ui", "lib_a"), "lib_a")
+	writePubspec(t, filepath.Join(root, "packages", "ui", "lib_b"), "lib_b")
+	markGit(t, filepath.Join(root, "packages", "ui"), "")
+
+	// A package deeper than the other ones
+	writePubspec(t, filepath.Join(root, "apps", "mobile"), "app")
+	markGit(t, filepath.Join(root, "apps", "mobile"), "")
+
+	// Worktrees, linked or left behind, and ignored directories
+	writePubspec(t, filepath.Join(root, "core-login"), "core")
+	markGit(t, filepath.Join(root, "core-login"), "gitdir: /ws/core/.git/worktrees/core-login\n")
+	writePubspec(t, filepath.Join(root, "core-feature-1"), "core")
+	writePubspec(t, filepath.Join(root, "build", "generated"), "generated")
+	writePubspec(t, filepath.Join(root, "legacy"), "legacy")
+
+	t.Run("default depth only looks at direct children", func(t *testing.T) {
+		packages, _, err := Scan(root, Options{WorktreeSuffixes: []string{"-feature-1"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var paths []string
+		for _, pkg := range packages {
+			paths = append(paths, pkg.Path)
+		}
+		expected := []string{"./core", "./legacy"}
+		if !reflect.DeepEqual(paths, expected) {
+			t.Errorf("Expected %v, got %v", expected, paths)
+		}
+	})
+
+	t.Run("deeper scan groups packages by repository", func(t *testing.T) {
+		packages, _, err := Scan(root, Options{
+			Depth:            3,
+			Ignore:           []string{"legacy"},
+			WorktreeSuffixes: []string{"-feature-1"},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expected := []Package{
+			{Name: "app", Path: "./apps/mobile", Repo: "./apps/mobile"},
+			{Name: "core", Path: "./core", Repo: "./core", Nested: []string{"./core/example"}},
+			{Name: "lib_a", Path: "./packages/ui/lib_a", Repo: "./packages/ui", Nested: []string{"./packages/ui/lib_b"}},
+		}
+		if !reflect.DeepEqual(packages, expected) {
+			t.Errorf("Expected %+v, got %+v", expected, packages)
+		}
+	})
+}
+
+// cloneRepo creates a bare remote whose default branch is branch, holding
+// pubspec, and clones it into root/workspace/name
+func cloneRepo(t *testing.T, root, name, branch, pubspec string) string {
+	t.Helper()
+
+	remote := testutil.CreateRemote(t, root, name, branch, map[string]string{"pubspec.yaml": pubspec})
+	testutil.RunGit(t, root, "clone", "-q", remote, filepath.Join(root, "workspace", name))
+
+	return remote
+}
+
+func TestInfer(t *testing.T) {
+	testutil.SetGitIdentity(t)
+
+	root := t.TempDir()
+	workspace := filepath.Join(root, "workspace")
+
+	cloneRepo(t, root, "core", "main", "name: core\n")
+	uiRemote := cloneRepo(t, root, "ui", "develop", "name: ui\ndependencies:\n  core:\n    path: ../core\n")
+	cloneRepo(t, root, "app", "main", "name: app\ndependencies:\n  flutter:\n    sdk: flutter\n  core:\n    path: ../core\n  ui:\n    path: ../ui\n")
+
+	packages, _, err := Scan(workspace, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	inference := Infer(workspace, packages)
+	if inference.Master < 0 || inference.Repos[inference.Master].Name != "app" {
+		t.Fatalf("Expected app to be the master repository, got %d", inference.Master)
+	}
+	if inference.MainBranch != "main" {
+		t.Errorf("Expected main to be the shared main branch, got %s", inference.MainBranch)
+	}
+
+	for _, repo := range inference.Repos {
+		if repo.Name == "ui" {
+			if repo.MainBranch != "develop" {
+				t.Errorf("Expected ui main branch develop from origin/HEAD, got %s", repo.MainBranch)
+			}
+			if repo.URL != uiRemote {
+				t.Errorf("Expected ui url %s, got %s", uiRemote, repo.URL)
+			}
+		}
+	}
+
+	// Without sibling dependencies the Flutter app wins
+	writePubspec(t, filepath.Join(root, "solo", "lib_pkg"), "lib_pkg")
+	writePubspec(t, filepath.Join(root, "solo", "mobile"), "mobile")
+	if err := os.MkdirAll(filepath.Join(root, "solo", "mobile", "lib"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "solo", "mobile", "lib", "main.dart"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	packages, _, err = Scan(filepath.Join(root, "solo"), Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	inference = Infer(filepath.Join(root, "solo"), packages)
+	if inference.Master < 0 || inference.Repos[inference.Master].Name != "mobile" || inference.MasterReason != "has lib/main.dart" {
+		t.Errorf("Expected mobile to be the master because of lib/main.dart, got %d (%s)", inference.Master, inference.MasterReason)
+	}
+}
@@ -0,0 +1,250 @@
+package status
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/viniciusamelio/alfred/internal/config"
+	"github.com/viniciusamelio/alfred/internal/git"
+	"github.com/viniciusamelio/alfred/internal/pubspec"
+)
+
+const (
+	DepsLinked = "linked"
+	DepsGit    = "git"
+	DepsMixed  = "mixed"
+	DepsNone   = "-"
+)
+
+// RepoStatus is the state of a single repository checkout within a context
+type RepoStatus struct {
+	Name       string
+	Path       string
+	IsMaster   bool
+	Exists     bool
+	Branch     string
+	Upstream   string
+	Ahead      int
+	Behind     int
+	Changes    git.ChangeCounts
+	Stashes    int
+	LastCommit *git.CommitInfo
+	Deps       string
+	Error      string
+}
+
+// Options controls how repository status is collected
+type Options struct {
+	// Fetch updates remote-tracking branches before computing ahead/behind
+	Fetch bool
+}
+
+// CollectRepoStatus gathers the status of repo checked out at path. siblings are
+// the other repositories of the context, used to tell whether the pubspec
+// dependencies on them are linked to local paths or still point to git.
+func CollectRepoStatus(repo *config.Repository, path string, siblings []*config.Repository, isMaster bool, opts Options) *RepoStatus {
+	repoIdentifier := repo.Identifier()
+
+	st := &RepoStatus{
+		Name:     repoIdentifier,
+		Path:     path,
+		IsMaster: isMaster,
+		Deps:     DepsNone,
+	}
+
+	gitRepo := git.NewGitRepo(path)
+	if !gitRepo.IsGitRepo() {
+		st.Error = "no checkout (not switched to this context yet)"
+		return st
+	}
+	st.Exists = true
+
+	if opts.Fetch {
+		if err := gitRepo.Fetch(repo.GetRemote()); err != nil {
+			st.Error = err.Error()
+		}
+	}
+
+	if branch, err := gitRepo.GetCurrentBranch(); err == nil {
+		st.Branch = branch
+	}
+
+	if upstream, err := gitRepo.GetUpstream(); err == nil {
+		st.Upstream = upstream
+		if ahead, behind, err := gitRepo.GetAheadBehind("HEAD", upstream); err == nil {
+			st.Ahead = ahead
+			st.Behind = behind
+		}
+	}
+
+	if changes, err := gitRepo.GetChangeCounts(); err == nil {
+		st.Changes = changes
+	} else if st.Error == "" {
+		st.Error = err.Error()
+	}
+
+	if stashes, err := gitRepo.ListStashes(); err == nil {
+		st.Stashes = len(stashes)
+	}
+
+	if commit, err := gitRepo.GetLastCommit("HEAD"); err == nil {
+		st.LastCommit = commit
+	}
+
+	st.Deps = dependencyState(path, siblings)
+	return st
+}
+
+// dependencyState summarizes how the pubspec at path depends on the sibling repositories
+func dependencyState(path string, siblings []*config.Repository) string {
+	if _, err := os.Stat(filepath.Join(path, "pubspec.yaml")); err != nil {
+		return DepsNone
+	}
+
+	pubspecFile, err := pubspec.LoadPubspec(path)
+	if err != nil {
+		return DepsNone
+	}
+
+	linked, gitBased := 0, 0
+	for _, sibling := range siblings {
+		switch pubspecFile.GetDependencySource(sibling.Name) {
+		case pubspec.DependencyPath:
+			linked++
+		case pubspec.DependencyGit:
+			gitBased++
+		}
+	}
+
+	switch {
+	case linked > 0 && gitBased > 0:
+		return DepsMixed
+	case linked > 0:
+		return DepsLinked
+	case gitBased > 0:
+		return DepsGit
+	default:
+		return DepsNone
+	}
+}
+
+// FormatAge renders how long ago t was in a compact form, e.g. "3d" or "5h"
+func FormatAge(t time.Time) string {
+	age := time.Since(t)
+	switch {
+	case age < time.Minute:
+		return "now"
+	case age < time.Hour:
+		return fmt.Sprintf("%dm", int(age.Minutes()))
+	case age < 24*time.Hour:
+		return fmt.Sprintf("%dh", int(age.Hours()))
+	case age < 30*24*time.Hour:
+		return fmt.Sprintf("%dd", int(age.Hours()/24))
+	case age < 365*24*time.Hour:
+		return fmt.Sprintf("%dmo", int(age.Hours()/24/30))
+	default:
+		return fmt.Sprintf("%dy", int(age.Hours()/24/365))
+	}
+}
+
+// BranchStatus is the state of a context branch in one repository, used to
+// spot forgotten work and contexts that can be deleted
+type BranchStatus struct {
+	InContext      bool
+	BranchExists   bool
+	UsesWorktree   bool
+	WorktreeExists bool
+	Dirty          bool
+	Unpushed       int
+	Merged         bool
+	LastCommit     time.Time
+}
+
+// CollectBranchStatus inspects branch in repo. checkoutPath is where the branch
+// is checked out (its worktree, or the repository itself), or empty if it is not.
+// mainRef is the ref the branch is compared against to decide if it was merged.
+func CollectBranchStatus(repo *config.Repository, branch, mainRef, checkoutPath string, usesWorktree bool) *BranchStatus {
+	st := &BranchStatus{
+		InContext:    true,
+		UsesWorktree: usesWorktree,
+	}
+
+	gitRepo := git.NewGitRepo(repo.Path)
+	if !gitRepo.IsGitRepo() {
+		return st
+	}
+
+	if exists, err := gitRepo.BranchExists(branch); err != nil || !exists {
+		return st
+	}
+	st.BranchExists = true
+
+	if checkoutPath != "" {
+		checkout := git.NewGitRepo(checkoutPath)
+		if checkout.IsGitRepo() {
+			st.WorktreeExists = usesWorktree
+			if files, err := checkout.GetChangedFiles(); err == nil {
+				st.Dirty = HasUserChanges(checkout, files)
+			}
+		}
+	}
+
+	if unpushed, err := gitRepo.CountUnpushedCommits(branch); err == nil {
+		st.Unpushed = unpushed
+	}
+
+	// A branch without commits of its own is an ancestor of main too, but
+	// there is nothing merged
+	if merged, err := gitRepo.IsMergedInto(branch, mainRef); err == nil && merged {
+		onMain, err := gitRepo.IsOnFirstParentHistory(branch, mainRef)
+		st.Merged = err == nil && !onMain
+	}
+
+	if commit, err := gitRepo.GetLastCommit(branch); err == nil {
+		st.LastCommit = commit.Date
+	}
+
+	return st
+}
+
+// HasUserChanges reports whether any of the changed files of checkout holds
+// work of the user. The pubspec backup alfred writes is ignored, and so are the
+// pubspec.yaml files it rewrote when linking a context, with the pubspec.lock
+// next to them; any other pubspec change belongs to the user.
+func HasUserChanges(checkout *git.GitRepo, files []string) bool {
+	linked := make(map[string]bool)
+	for _, file := range files {
+		if filepath.Base(file) == "pubspec.yaml" {
+			linked[filepath.Dir(file)] = isLinkRewrite(checkout, file)
+		}
+	}
+
+	for _, file := range files {
+		switch filepath.Base(file) {
+		case "pubspec.yaml.backup":
+			continue
+		case "pubspec.yaml", "pubspec.lock":
+			if linked[filepath.Dir(file)] {
+				continue
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// isLinkRewrite reports whether the changes to a pubspec.yaml of checkout are
+// only the path dependencies alfred links
+func isLinkRewrite(checkout *git.GitRepo, file string) bool {
+	committed, err := checkout.GetCommittedFileContent(file)
+	if err != nil {
+		return false
+	}
+	current, err := checkout.GetFileContent(file)
+	if err != nil {
+		return false
+	}
+	return pubspec.IsLinkRewrite(committed, current)
+}
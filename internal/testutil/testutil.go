@@ -0,0 +1,96 @@
+// Package testutil holds the fixtures shared by the tests of the other
+// packages: git repositories and remotes created in temporary directories.
+package testutil
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// RunGit runs a git command in dir and fails the test if it does not succeed
+func RunGit(t *testing.T, dir string, args ...string) {
+	t.Helper()
+
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %v failed: %v\n%s", args, err, output)
+	}
+}
+
+// SetGitIdentity sets the author and committer of the commits made by the
+// test, so it does not depend on the git configuration of the machine
+func SetGitIdentity(t *testing.T) {
+	t.Helper()
+
+	t.Setenv("GIT_AUTHOR_NAME", "Test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "Test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+}
+
+// Chdir changes the working directory to dir for the rest of the test
+func Chdir(t *testing.T, dir string) {
+	t.Helper()
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(wd) })
+}
+
+// WriteFile writes content to name inside dir, creating missing directories
+func WriteFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+
+	path := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// InitRepo creates a repository in dir on branch, with a first commit holding
+// files, a map of relative paths to contents. The commit is empty when files
+// is empty.
+func InitRepo(t *testing.T, dir, branch string, files map[string]string) {
+	t.Helper()
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	RunGit(t, dir, "init", "-q", "-b", branch)
+	if len(files) == 0 {
+		RunGit(t, dir, "commit", "-q", "--allow-empty", "-m", "init")
+		return
+	}
+
+	for name, content := range files {
+		WriteFile(t, dir, name, content)
+	}
+	RunGit(t, dir, "add", ".")
+	RunGit(t, dir, "commit", "-q", "-m", "init")
+}
+
+// CreateRemote creates root/remotes/<name>.git, a bare repository whose
+// default branch is branch, seeded with a first commit holding files, and
+// returns its path
+func CreateRemote(t *testing.T, root, name, branch string, files map[string]string) string {
+	t.Helper()
+
+	remote := filepath.Join(root, "remotes", name+".git")
+	seed := filepath.Join(root, "seed", name)
+
+	RunGit(t, root, "init", "-q", "--bare", "-b", branch, remote)
+	InitRepo(t, seed, branch, files)
+	RunGit(t, seed, "push", "-q", remote, branch)
+
+	return remote
+}
@@ -80,7 +80,9 @@ type CommitModel struct {
 	diffPanelWidth int                 // width of the diff panel
 }
 
-func NewCommitModel(repos map[string]*git.GitRepo) (*CommitModel, error) {
+// NewCommitModel lists the changes of repos, with message prefilled as the
+// commit message when it is not empty
+func NewCommitModel(repos map[string]*git.GitRepo, message string) (*CommitModel, error) {
 	// Get all file changes from all repositories
 	var allItems []CommitItem
 	repoMap := make(map[string][]*git.GitRepo)
@@ -122,6 +124,7 @@ func NewCommitModel(repos map[string]*git.GitRepo) (*CommitModel, error) {
 	ta.CharLimit = 500
 	ta.SetWidth(60)
 	ta.SetHeight(5)
+	ta.SetValue(message)
 
 	// Initialize viewport for diff view
 	vp := viewport.New(80, 20)
@@ -929,8 +932,8 @@ func max(a, b int) int {
 	return b
 }
 
-func RunCommitInterface(repos map[string]*git.GitRepo) error {
-	m, err := NewCommitModel(repos)
+func RunCommitInterface(repos map[string]*git.GitRepo, message string) error {
+	m, err := NewCommitModel(repos, message)
 	if err != nil {
 		return fmt.Errorf("failed to create commit model: %w", err)
 	}
@@ -0,0 +1,761 @@
+package tui
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/viniciusamelio/alfred/internal/config"
+	"github.com/viniciusamelio/alfred/internal/git"
+	"github.com/viniciusamelio/alfred/internal/worktree"
+)
+
+var (
+	editorTabStyle = lipgloss.NewStyle().
+			Padding(0, 1).
+			Foreground(lipgloss.Color("243"))
+
+	editorActiveTabStyle = lipgloss.NewStyle().
+				Padding(0, 1).
+				Background(lipgloss.Color("62")).
+				Foreground(lipgloss.Color("230")).
+				Bold(true)
+
+	editorValidStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.Color("86"))
+)
+
+const (
+	tabRepos = iota
+	tabSettings
+	tabContexts
+)
+
+var editorTabs = []string{"Repositories", "Settings", "Contexts"}
+
+const (
+	settingMaster = iota
+	settingMode
+	settingMainBranch
+)
+
+const (
+	formRepo = iota
+	formContext
+	formMainBranch
+)
+
+// editorForm is the dialog used to edit a repository, a context or the main branch
+type editorForm struct {
+	kind   int
+	target string // repository alias or context name being edited, empty when adding
+	labels []string
+	inputs []textinput.Model
+	focus  int // index of the focused input, len(inputs) for the repository list
+	repos  []repoItem
+	cursor int
+	err    string
+}
+
+type configEditorModel struct {
+	cfg         *config.Config
+	tab         int
+	cursor      int
+	form        *editorForm
+	problems    []config.Problem
+	dirty       bool
+	confirmQuit bool
+	saved       bool
+	cancelled   bool
+	err         string
+
+	// saved contexts may have branches and worktrees on disk, so the editor
+	// leaves removing them or their repositories to the context commands
+	savedContexts map[string][]string
+}
+
+func newConfigEditor(cfg *config.Config) configEditorModel {
+	savedContexts := make(map[string][]string, len(cfg.Contexts))
+	for name, aliases := range cfg.Contexts {
+		savedContexts[name] = slices.Clone(aliases)
+	}
+
+	return configEditorModel{
+		cfg:           cfg,
+		problems:      cfg.Validate(),
+		savedContexts: savedContexts,
+	}
+}
+
+func (m configEditorModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m configEditorModel) contextNames() []string {
+	var names []string
+	for name := range m.cfg.Contexts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (m configEditorModel) rowCount() int {
+	switch m.tab {
+	case tabRepos:
+		return len(m.cfg.Repos)
+	case tabSettings:
+		return 3
+	default:
+		return len(m.cfg.Contexts)
+	}
+}
+
+// changed revalidates the configuration after an edit
+func (m *configEditorModel) changed() {
+	m.dirty = true
+	m.err = ""
+	m.problems = m.cfg.Validate()
+	if m.cursor >= m.rowCount() && m.cursor > 0 {
+		m.cursor = m.rowCount() - 1
+	}
+}
+
+func (m configEditorModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+
+	if keyMsg.String() == "ctrl+c" {
+		m.cancelled = true
+		return m, tea.Quit
+	}
+
+	if m.form != nil {
+		return m.updateForm(keyMsg)
+	}
+
+	if m.confirmQuit {
+		switch keyMsg.String() {
+		case "y", "Y":
+			m.cancelled = true
+			return m, tea.Quit
+		default:
+			m.confirmQuit = false
+		}
+		return m, nil
+	}
+
+	switch keyMsg.String() {
+	case "q", "esc":
+		if m.dirty {
+			m.confirmQuit = true
+			return m, nil
+		}
+		m.cancelled = true
+		return m, tea.Quit
+
+	case "ctrl+s", "s":
+		if len(config.Errors(m.problems)) > 0 {
+			m.err = "Fix the problems below before saving"
+			return m, nil
+		}
+		if err := m.cfg.Save(); err != nil {
+			m.err = err.Error()
+			return m, nil
+		}
+		m.saved = true
+		return m, tea.Quit
+
+	case "tab", "right", "l":
+		m.tab = (m.tab + 1) % len(editorTabs)
+		m.cursor = 0
+
+	case "shift+tab", "left", "h":
+		m.tab = (m.tab + len(editorTabs) - 1) % len(editorTabs)
+		m.cursor = 0
+
+	case "up", "k":
+		if m.cursor > 0 {
+			m.cursor--
+		}
+
+	case "down", "j":
+		if m.cursor < m.rowCount()-1 {
+			m.cursor++
+		}
+
+	case "enter", "e":
+		return m.edit()
+
+	case "a":
+		switch m.tab {
+		case tabRepos:
+			m.form = m.newRepoForm(nil)
+		case tabContexts:
+			m.form = m.newContextForm("")
+		}
+		if m.form != nil {
+			return m, m.form.focusInput()
+		}
+
+	case "d", "delete":
+		m.delete()
+	}
+
+	return m, nil
+}
+
+func (m configEditorModel) edit() (tea.Model, tea.Cmd) {
+	if m.rowCount() == 0 {
+		return m, nil
+	}
+
+	switch m.tab {
+	case tabRepos:
+		m.form = m.newRepoForm(&m.cfg.Repos[m.cursor])
+
+	case tabSettings:
+		switch m.cursor {
+		case settingMaster:
+			// Cycle through the repositories
+			aliases := m.cfg.GetRepoAliases()
+			if len(aliases) == 0 {
+				return m, nil
+			}
+			next := 0
+			for i, alias := range aliases {
+				if alias == m.cfg.Master {
+					next = (i + 1) % len(aliases)
+				}
+			}
+			m.cfg.Master = aliases[next]
+			m.changed()
+			return m, nil
+
+		case settingMode:
+			// The contexts following the global mode would lose track of their worktrees
+			if contexts := contextsWithWorktrees(m.cfg); len(contexts) > 0 {
+				m.err = fmt.Sprintf("Contexts %s have worktrees. Delete them with 'alfred delete' or keep their mode with 'alfred context describe <context> --mode %s' first",
+					strings.Join(contexts, ", "), m.cfg.Mode)
+				return m, nil
+			}
+			if m.cfg.Mode == config.ModeBranch {
+				m.cfg.Mode = config.ModeWorktree
+			} else {
+				m.cfg.Mode = config.ModeBranch
+			}
+			m.changed()
+			return m, nil
+
+		case settingMainBranch:
+			m.form = &editorForm{
+				kind:   formMainBranch,
+				labels: []string{"Main branch"},
+				inputs: []textinput.Model{newEditorInput(m.cfg.GetMainBranch(), "main")},
+			}
+		}
+
+	case tabContexts:
+		m.form = m.newContextForm(m.contextNames()[m.cursor])
+	}
+
+	if m.form == nil {
+		return m, nil
+	}
+	return m, m.form.focusInput()
+}
+
+func (m *configEditorModel) delete() {
+	switch m.tab {
+	case tabRepos:
+		if len(m.cfg.Repos) == 0 {
+			return
+		}
+		alias := m.cfg.GetRepoAliases()[m.cursor]
+		if contexts := m.savedContextsWith(alias); len(contexts) > 0 {
+			m.err = fmt.Sprintf("'%s' is used by contexts %s. Remove it with 'alfred context remove-repo <context> %s' first",
+				alias, strings.Join(contexts, ", "), alias)
+			return
+		}
+		if err := m.cfg.RemoveRepo(alias); err != nil {
+			m.err = err.Error()
+			return
+		}
+		m.changed()
+
+	case tabContexts:
+		names := m.contextNames()
+		if len(names) == 0 {
+			return
+		}
+		name := names[m.cursor]
+		if _, saved := m.savedContexts[name]; saved {
+			m.err = fmt.Sprintf("Delete context '%s' with 'alfred delete %s', which also removes its worktrees and branches", name, name)
+			return
+		}
+		if err := m.cfg.RemoveContext(name); err != nil {
+			m.err = err.Error()
+			return
+		}
+		m.changed()
+	}
+}
+
+// savedContextsWith returns the saved contexts that still contain a repository
+func (m configEditorModel) savedContextsWith(alias string) []string {
+	var contexts []string
+	for name := range m.savedContexts {
+		if m.cfg.ContextContainsRepo(name, alias) {
+			contexts = append(contexts, name)
+		}
+	}
+	sort.Strings(contexts)
+	return contexts
+}
+
+// contextsWithWorktrees returns the contexts following the global mode that
+// have worktrees on disk, which changing the mode would orphan
+func contextsWithWorktrees(cfg *config.Config) []string {
+	worktrees := worktree.NewManager(cfg)
+
+	var contexts []string
+	for name := range cfg.Contexts {
+		if cfg.GetContextInfo(name).Mode != "" {
+			continue
+		}
+		repos, err := cfg.GetContextRepos(name)
+		if err != nil {
+			continue
+		}
+		for _, repo := range repos {
+			if repo.Identifier() == cfg.Master {
+				continue
+			}
+			exists, err := git.NewGitRepo(repo.Path).WorktreeExists(worktrees.GetWorktreePath(repo, name))
+			if err == nil && exists {
+				contexts = append(contexts, name)
+				break
+			}
+		}
+	}
+	sort.Strings(contexts)
+	return contexts
+}
+
+func newEditorInput(value, placeholder string) textinput.Model {
+	input := textinput.New()
+	input.CharLimit = 200
+	input.Width = 40
+	input.Placeholder = placeholder
+	input.SetValue(value)
+	return input
+}
+
+func (m configEditorModel) newRepoForm(repo *config.Repository) *editorForm {
+	form := &editorForm{
+		kind:   formRepo,
+		labels: []string{"Name", "Alias", "Path", "Remote"},
+	}
+
+	var name, alias, path, remote string
+	if repo != nil {
+		form.target = repo.Alias
+		if form.target == "" {
+			form.target = repo.Name
+		}
+		name, alias, path, remote = repo.Name, repo.Alias, repo.Path, repo.Remote
+	}
+
+	form.inputs = []textinput.Model{
+		newEditorInput(name, "package name from pubspec.yaml"),
+		newEditorInput(alias, "optional"),
+		newEditorInput(path, "./my_package"),
+		newEditorInput(remote, config.DefaultRemote),
+	}
+	return form
+}
+
+func (m configEditorModel) newContextForm(name string) *editorForm {
+	form := &editorForm{
+		kind:   formContext,
+		target: name,
+	}
+
+	if name == "" {
+		form.labels = []string{"Context name"}
+		form.inputs = []textinput.Model{newEditorInput("", "feature-1")}
+	} else {
+		form.focus = 0 // the repository list, as there are no inputs
+	}
+
+	// Groups are kept as references, so they are listed next to the
+	// repositories rather than expanded
+	refs := m.cfg.Contexts[name]
+	for _, group := range m.cfg.GetGroupNames() {
+		form.repos = append(form.repos, repoItem{
+			alias:   config.GroupPrefix + group,
+			path:    strings.Join(m.cfg.Groups[group], ", "),
+			checked: slices.Contains(refs, config.GroupPrefix+group),
+		})
+	}
+	for i, alias := range m.cfg.GetRepoAliases() {
+		form.repos = append(form.repos, repoItem{
+			alias:   alias,
+			path:    m.cfg.Repos[i].Path,
+			checked: slices.Contains(refs, alias),
+		})
+	}
+	return form
+}
+
+// focusInput focuses the current input and blurs the others
+func (f *editorForm) focusInput() tea.Cmd {
+	var cmd tea.Cmd
+	for i := range f.inputs {
+		if i == f.focus {
+			cmd = f.inputs[i].Focus()
+		} else {
+			f.inputs[i].Blur()
+		}
+	}
+	return cmd
+}
+
+func (f *editorForm) fieldCount() int {
+	if f.kind == formContext {
+		return len(f.inputs) + 1
+	}
+	return len(f.inputs)
+}
+
+func (m configEditorModel) updateForm(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	form := m.form
+	onList := form.kind == formContext && form.focus == len(form.inputs)
+
+	switch msg.String() {
+	case "esc":
+		m.form = nil
+		return m, nil
+
+	case "tab", "shift+tab":
+		if msg.String() == "tab" {
+			form.focus = (form.focus + 1) % form.fieldCount()
+		} else {
+			form.focus = (form.focus + form.fieldCount() - 1) % form.fieldCount()
+		}
+		return m, form.focusInput()
+
+	case "up", "down":
+		if onList {
+			if msg.String() == "up" && form.cursor > 0 {
+				form.cursor--
+			}
+			if msg.String() == "down" && form.cursor < len(form.repos)-1 {
+				form.cursor++
+			}
+			return m, nil
+		}
+		if form.kind == formRepo {
+			if msg.String() == "down" {
+				form.focus = (form.focus + 1) % form.fieldCount()
+			} else {
+				form.focus = (form.focus + form.fieldCount() - 1) % form.fieldCount()
+			}
+			return m, form.focusInput()
+		}
+
+	case " ":
+		if onList && len(form.repos) > 0 {
+			form.repos[form.cursor].checked = !form.repos[form.cursor].checked
+			form.err = ""
+			return m, nil
+		}
+
+	case "enter":
+		if err := m.applyForm(); err != nil {
+			form.err = err.Error()
+			return m, nil
+		}
+		m.form = nil
+		m.changed()
+		return m, nil
+	}
+
+	if form.focus < len(form.inputs) {
+		var cmd tea.Cmd
+		form.inputs[form.focus], cmd = form.inputs[form.focus].Update(msg)
+		return m, cmd
+	}
+	return m, nil
+}
+
+// applyForm writes the form values into the configuration
+func (m *configEditorModel) applyForm() error {
+	form := m.form
+	value := func(i int) string {
+		return strings.TrimSpace(form.inputs[i].Value())
+	}
+
+	switch form.kind {
+	case formRepo:
+		repo := config.Repository{}
+		if form.target != "" {
+			existing, err := m.cfg.GetRepoByAlias(form.target)
+			if err != nil {
+				return err
+			}
+			repo = *existing
+		}
+		repo.Name, repo.Alias, repo.Path, repo.Remote = value(0), value(1), value(2), value(3)
+
+		if repo.Name == "" || repo.Path == "" {
+			return fmt.Errorf("name and path are required")
+		}
+		if form.target == "" {
+			return m.cfg.AddRepo(repo)
+		}
+		return m.cfg.UpdateRepo(form.target, repo)
+
+	case formContext:
+		name := form.target
+		if name == "" {
+			name = value(0)
+			if name == "" {
+				return fmt.Errorf("context name cannot be empty")
+			}
+			if name == "main" || name == "master" || m.cfg.ContextExists(name) {
+				return fmt.Errorf("context '%s' already exists", name)
+			}
+		}
+
+		var selected []string
+		for _, repo := range form.repos {
+			if repo.checked {
+				selected = append(selected, repo.alias)
+			}
+		}
+		if len(selected) == 0 {
+			return fmt.Errorf("please select at least one repository")
+		}
+		for _, alias := range m.savedContexts[name] {
+			if !slices.Contains(selected, alias) {
+				return fmt.Errorf("remove '%s' with 'alfred context remove-repo %s %s', which also removes its worktree and branch", alias, name, alias)
+			}
+		}
+		return m.cfg.AddContext(name, selected)
+
+	case formMainBranch:
+		if value(0) == "" {
+			return fmt.Errorf("main branch cannot be empty")
+		}
+		m.cfg.MainBranch = value(0)
+	}
+	return nil
+}
+
+func (m configEditorModel) View() string {
+	if m.cancelled {
+		return "Configuration left unchanged.\n"
+	}
+	if m.saved {
+		return scannerSuccessStyle.Render("✅ Configuration saved") + "\n"
+	}
+
+	var b strings.Builder
+	b.WriteString(creatorTitleStyle.Render("⚙️  Alfred configuration"))
+	b.WriteString("\n")
+
+	var tabs []string
+	for i, name := range editorTabs {
+		if i == m.tab {
+			tabs = append(tabs, editorActiveTabStyle.Render(name))
+		} else {
+			tabs = append(tabs, editorTabStyle.Render(name))
+		}
+	}
+	b.WriteString(strings.Join(tabs, " "))
+	b.WriteString("\n\n")
+
+	if m.form != nil {
+		b.WriteString(m.viewForm())
+	} else {
+		b.WriteString(m.viewTab())
+	}
+
+	b.WriteString("\n")
+	if len(m.problems) == 0 {
+		b.WriteString(editorValidStyle.Render("✅ Configuration is valid"))
+		b.WriteString("\n")
+	} else {
+		label := "problems"
+		if len(m.problems) == 1 {
+			label = "problem"
+		}
+		b.WriteString(creatorErrorStyle.Render(fmt.Sprintf("❌ %d %s:", len(m.problems), label)))
+		b.WriteString("\n")
+		for _, problem := range m.problems {
+			bullet := "   • "
+			if problem.Warning {
+				bullet = "   • warning: "
+			}
+			b.WriteString(creatorErrorStyle.UnsetMarginTop().Render(bullet + problem.Error()))
+			b.WriteString("\n")
+		}
+	}
+
+	if m.err != "" {
+		b.WriteString(creatorErrorStyle.Render(m.err))
+		b.WriteString("\n")
+	}
+
+	switch {
+	case m.confirmQuit:
+		b.WriteString(helpTextStyle.Render("Discard unsaved changes? (y/N)"))
+	case m.form != nil && m.form.kind == formContext:
+		b.WriteString(helpTextStyle.Render("Tab switch field • ↑/↓ navigate • Space toggle • Enter apply • Esc back"))
+	case m.form != nil:
+		b.WriteString(helpTextStyle.Render("Tab/↑/↓ switch field • Enter apply • Esc back"))
+	case m.tab == tabSettings:
+		b.WriteString(helpTextStyle.Render("←/→ switch tab • ↑/↓ navigate • Enter change • s save • q quit"))
+	default:
+		b.WriteString(helpTextStyle.Render("←/→ switch tab • ↑/↓ navigate • Enter edit • a add • d delete • s save • q quit"))
+	}
+
+	return b.String()
+}
+
+func (m configEditorModel) viewTab() string {
+	var b strings.Builder
+	row := func(i int, line string) {
+		if i == m.cursor {
+			b.WriteString(selectedCheckboxStyle.Render("> " + line))
+		} else {
+			b.WriteString(checkboxStyle.Render(line))
+		}
+		b.WriteString("\n")
+	}
+
+	switch m.tab {
+	case tabRepos:
+		if len(m.cfg.Repos) == 0 {
+			b.WriteString(checkboxStyle.Render("No repositories. Press 'a' to add one."))
+			b.WriteString("\n")
+		}
+		for i, repo := range m.cfg.Repos {
+			line := repo.Name
+			if repo.Alias != "" {
+				line += fmt.Sprintf(" (alias %s)", repo.Alias)
+			}
+			if m.cfg.Master != "" && m.cfg.GetRepoAliases()[i] == m.cfg.Master {
+				line += " ★ master"
+			}
+			line += reviewDetailStyle.Render(fmt.Sprintf("  %s • remote %s", repo.Path, repo.GetRemote()))
+			row(i, line)
+		}
+
+	case tabSettings:
+		master := m.cfg.Master
+		if master == "" {
+			master = "(none)"
+		}
+		row(settingMaster, fmt.Sprintf("Master repository  %s", master))
+		row(settingMode, fmt.Sprintf("Mode               %s", m.cfg.Mode))
+		row(settingMainBranch, fmt.Sprintf("Main branch        %s", m.cfg.GetMainBranch()))
+
+	case tabContexts:
+		names := m.contextNames()
+		if len(names) == 0 {
+			b.WriteString(checkboxStyle.Render("No contexts. Press 'a' to add one."))
+			b.WriteString("\n")
+		}
+		for i, name := range names {
+			line := name
+			if m.cfg.IsSharedContext(name) {
+				line += " (shared)"
+			}
+			line += reviewDetailStyle.Render("  " + strings.Join(m.cfg.Contexts[name], ", "))
+			row(i, line)
+		}
+	}
+
+	return b.String()
+}
+
+func (m configEditorModel) viewForm() string {
+	form := m.form
+	var b strings.Builder
+
+	switch {
+	case form.kind == formRepo && form.target == "":
+		b.WriteString(inputLabelStyle.Render("New repository"))
+	case form.kind == formRepo:
+		b.WriteString(inputLabelStyle.Render(fmt.Sprintf("Edit repository '%s'", form.target)))
+	case form.kind == formContext && form.target == "":
+		b.WriteString(inputLabelStyle.Render("New context"))
+	case form.kind == formContext:
+		b.WriteString(inputLabelStyle.Render(fmt.Sprintf("Repositories of context '%s'", form.target)))
+	default:
+		b.WriteString(inputLabelStyle.Render("Main branch"))
+	}
+	b.WriteString("\n")
+
+	for i, input := range form.inputs {
+		b.WriteString(fmt.Sprintf("  %-14s %s\n", form.labels[i], input.View()))
+	}
+
+	if form.kind == formContext {
+		if len(form.inputs) > 0 {
+			b.WriteString("\n")
+		}
+		onList := form.focus == len(form.inputs)
+		for i, repo := range form.repos {
+			cursor := " "
+			if onList && form.cursor == i {
+				cursor = ">"
+			}
+			checked := "☐"
+			style := checkboxStyle
+			if repo.checked {
+				checked = "☑"
+				style = checkedStyle
+			}
+			line := fmt.Sprintf("%s %s %s (%s)", cursor, checked, repo.alias, repo.path)
+			if onList && form.cursor == i {
+				line = selectedCheckboxStyle.Render(line)
+			} else {
+				line = style.Render(line)
+			}
+			b.WriteString(line + "\n")
+		}
+	}
+
+	if form.err != "" {
+		b.WriteString(creatorErrorStyle.Render(form.err))
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// RunConfigEditor opens the configuration editor on a copy of cfg. Changes are
+// validated as they are made and only written, through Config.Save, when the
+// user saves a valid configuration. It reports whether the configuration was saved.
+func RunConfigEditor(cfg *config.Config) (bool, error) {
+	p := tea.NewProgram(newConfigEditor(cfg.Clone()))
+
+	finalModel, err := p.Run()
+	if err != nil {
+		return false, fmt.Errorf("error running config editor: %w", err)
+	}
+
+	model, ok := finalModel.(configEditorModel)
+	if !ok {
+		return false, fmt.Errorf("unexpected model type: %T", finalModel)
+	}
+	return model.saved, nil
+}
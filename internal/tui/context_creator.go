@@ -57,6 +57,7 @@ type ContextCreatorModel struct {
 	contextName   string
 	selectedRepos []string
 	error         string
+	title         string // overrides the repo selection title when set
 }
 
 func NewContextCreator(repoAliases []string, repoPaths []string) *ContextCreatorModel {
@@ -191,7 +192,11 @@ func (m ContextCreatorModel) View() string {
 		b.WriteString(helpTextStyle.Render("Press Enter to continue, Esc to cancel"))
 	} else {
 		// Repository selection step
-		b.WriteString(creatorTitleStyle.Render(fmt.Sprintf("Select repositories for '%s'", m.contextName)))
+		title := m.title
+		if title == "" {
+			title = fmt.Sprintf("Select repositories for '%s'", m.contextName)
+		}
+		b.WriteString(creatorTitleStyle.Render(title))
 		b.WriteString("\n\n")
 
 		for i, repo := range m.repos {
@@ -238,8 +243,18 @@ func (m ContextCreatorModel) GetResult() (string, []string, bool) {
 }
 
 func RunRepoSelector(repoAliases []string, repoPaths []string) ([]string, error) {
+	return RunRepoSelectorWithTitle("", repoAliases, repoPaths)
+}
+
+// RunRepoSelectorWithTitle runs the repository selection step on its own under a custom title
+func RunRepoSelectorWithTitle(title string, repoAliases []string, repoPaths []string) ([]string, error) {
+	if len(repoAliases) == 0 {
+		return nil, fmt.Errorf("no repositories available")
+	}
+
 	m := NewContextCreator(repoAliases, repoPaths)
 	m.step = 1 // Skip context name input, go directly to repo selection
+	m.title = title
 
 	p := tea.NewProgram(m)
 
@@ -49,6 +49,7 @@ type deleteContextItem struct {
 	name    string
 	current bool
 	checked bool
+	risks   []string // work that would be lost, one line per repository
 }
 
 type ContextDeleterModel struct {
@@ -63,7 +64,9 @@ type ContextDeleterModel struct {
 	currentContext string
 }
 
-func NewContextDeleter(contextNames []string, currentContext string) *ContextDeleterModel {
+// NewContextDeleter creates the deleter. risks maps a context to the work that
+// deleting it would discard, shown before the deletion is confirmed.
+func NewContextDeleter(contextNames []string, currentContext string, risks map[string][]string) *ContextDeleterModel {
 	ti := textinput.New()
 	ti.Focus()
 	ti.CharLimit = 20
@@ -76,6 +79,7 @@ func NewContextDeleter(contextNames []string, currentContext string) *ContextDel
 			name:    name,
 			current: name == currentContext,
 			checked: false,
+			risks:   risks[name],
 		}
 	}
 
@@ -211,6 +215,8 @@ func (m ContextDeleterModel) View() string {
 			status := ""
 			if ctx.current {
 				status = " (current - cannot delete)"
+			} else if len(ctx.risks) > 0 {
+				status = " ⚠️  has unsaved work"
 			}
 
 			line := fmt.Sprintf("%s %s %s%s", cursor, checked, ctx.name, status)
@@ -240,12 +246,23 @@ func (m ContextDeleterModel) View() string {
 		for _, name := range m.selectedNames {
 			b.WriteString(deleteErrorStyle.Render(fmt.Sprintf("• %s", name)))
 			b.WriteString("\n")
+			for _, ctx := range m.contexts {
+				if ctx.name != name {
+					continue
+				}
+				for _, risk := range ctx.risks {
+					b.WriteString(fmt.Sprintf("    ⚠️  %s\n", risk))
+				}
+			}
 		}
 		b.WriteString("\n")
 		b.WriteString(warningStyle.Render("This action will:"))
 		b.WriteString("\n")
 		b.WriteString("• Remove all worktrees for these contexts\n")
 		b.WriteString("• Delete branches for these contexts\n")
+		if m.hasSelectedRisks() {
+			b.WriteString("• Discard the unsaved work listed above\n")
+		}
 		b.WriteString("• Remove contexts from configuration\n")
 		b.WriteString("• THIS CANNOT BE UNDONE\n")
 		b.WriteString("\n")
@@ -266,6 +283,15 @@ func (m ContextDeleterModel) View() string {
 	return b.String()
 }
 
+func (m ContextDeleterModel) hasSelectedRisks() bool {
+	for _, ctx := range m.contexts {
+		if ctx.checked && len(ctx.risks) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
 func (m ContextDeleterModel) GetResult() ([]string, bool) {
 	if m.cancelled || !m.finished {
 		return nil, false
@@ -273,7 +299,7 @@ func (m ContextDeleterModel) GetResult() ([]string, bool) {
 	return m.selectedNames, true
 }
 
-func RunContextDeleter(contextNames []string, currentContext string) ([]string, error) {
+func RunContextDeleter(contextNames []string, currentContext string, risks map[string][]string) ([]string, error) {
 	if len(contextNames) == 0 {
 		return nil, fmt.Errorf("no contexts available")
 	}
@@ -294,7 +320,7 @@ func RunContextDeleter(contextNames []string, currentContext string) ([]string,
 		return nil, fmt.Errorf("cannot delete the only context, and it's currently active")
 	}
 
-	m := NewContextDeleter(deletableContexts, currentContext)
+	m := NewContextDeleter(deletableContexts, currentContext, risks)
 	p := tea.NewProgram(m)
 
 	finalModel, err := p.Run()
@@ -80,10 +80,12 @@ type ContextSelectorModel struct {
 	currentContext string
 }
 
-func NewContextSelector(contexts []string, currentContext string) *ContextSelectorModel {
+// NewContextSelector lists contexts with their details, such as the
+// description and when they were last used, keyed by context name
+func NewContextSelector(contexts []string, details map[string]string, currentContext string) *ContextSelectorModel {
 	items := make([]list.Item, len(contexts))
 	for i, ctx := range contexts {
-		description := ""
+		description := details[ctx]
 		if ctx == "main" {
 			description = "main/master branches for all repos"
 		}
@@ -164,12 +166,12 @@ func (m ContextSelectorModel) GetChoice() string {
 	return m.choice
 }
 
-func RunContextSelector(contexts []string, currentContext string) (string, error) {
+func RunContextSelector(contexts []string, details map[string]string, currentContext string) (string, error) {
 	if len(contexts) == 0 {
 		return "", fmt.Errorf("no contexts available")
 	}
 
-	m := NewContextSelector(contexts, currentContext)
+	m := NewContextSelector(contexts, details, currentContext)
 	p := tea.NewProgram(m)
 
 	finalModel, err := p.Run()
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 )
@@ -42,129 +43,158 @@ var (
 				Foreground(lipgloss.Color("86")).
 				Bold(true)
 
+	reviewDetailStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.Color("243"))
+
 	// packageCountStyle = lipgloss.NewStyle().
 	// 			Foreground(lipgloss.Color("39")).
 	// 			Bold(true)
 )
 
-type PackageInfo struct {
-	Name string
-	Path string
+// ReviewRepo is a scanned repository with its inferred settings
+type ReviewRepo struct {
+	Name       string
+	Path       string
+	MainBranch string
+	URL        string
 }
 
-type ScannerModel struct {
-	packages    []PackageInfo
-	cursor      int
-	finished    bool
-	cancelled   bool
-	selectedIdx int
-	title       string
-	subtitle    string
+type scanReviewModel struct {
+	repos        []ReviewRepo
+	master       int
+	masterReason string
+	cursor       int
+	editing      bool
+	input        textinput.Model
+	finished     bool
+	cancelled    bool
 }
 
-func NewScanner(packages []PackageInfo) *ScannerModel {
-	return &ScannerModel{
-		packages:    packages,
-		cursor:      0,
-		selectedIdx: -1,
-		title:       "🔍 Repository Scanner",
-		subtitle:    fmt.Sprintf("Found %d Dart/Flutter packages", len(packages)),
+func newScanReview(repos []ReviewRepo, master int, masterReason string) scanReviewModel {
+	input := textinput.New()
+	input.CharLimit = 100
+	input.Width = 30
+
+	cursor := 0
+	if master >= 0 {
+		cursor = master
+	}
+
+	return scanReviewModel{
+		repos:        repos,
+		master:       master,
+		masterReason: masterReason,
+		cursor:       cursor,
+		input:        input,
 	}
 }
 
-func (m ScannerModel) Init() tea.Cmd {
+func (m scanReviewModel) Init() tea.Cmd {
 	return nil
 }
 
-func (m ScannerModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
-	switch msg := msg.(type) {
-	case tea.KeyMsg:
-		switch msg.String() {
-		case "ctrl+c", "esc":
-			m.cancelled = true
-			return m, tea.Quit
+func (m scanReviewModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
 
+	if m.editing {
+		switch keyMsg.String() {
 		case "enter":
-			if len(m.packages) > 0 {
-				m.selectedIdx = m.cursor
-				m.finished = true
-				return m, tea.Quit
-			}
-
-		case "up", "k":
-			if m.cursor > 0 {
-				m.cursor--
-			}
-
-		case "down", "j":
-			if m.cursor < len(m.packages)-1 {
-				m.cursor++
-			}
+			m.repos[m.cursor].MainBranch = strings.TrimSpace(m.input.Value())
+			m.editing = false
+			m.input.Blur()
+		case "esc":
+			m.editing = false
+			m.input.Blur()
+		default:
+			var cmd tea.Cmd
+			m.input, cmd = m.input.Update(msg)
+			return m, cmd
+		}
+		return m, nil
+	}
+
+	switch keyMsg.String() {
+	case "ctrl+c", "esc", "q":
+		m.cancelled = true
+		return m, tea.Quit
+
+	case "up", "k":
+		if m.cursor > 0 {
+			m.cursor--
+		}
+
+	case "down", "j":
+		if m.cursor < len(m.repos)-1 {
+			m.cursor++
+		}
+
+	case " ", "m":
+		m.master = m.cursor
+		m.masterReason = ""
+
+	case "e":
+		m.editing = true
+		m.input.SetValue(m.repos[m.cursor].MainBranch)
+		m.input.CursorEnd()
+		return m, m.input.Focus()
+
+	case "enter":
+		if m.master >= 0 {
+			m.finished = true
+			return m, tea.Quit
 		}
 	}
 
 	return m, nil
 }
 
-func (m ScannerModel) View() string {
+func (m scanReviewModel) View() string {
 	if m.cancelled {
 		return scannerSuccessStyle.Render("Operation cancelled.\n")
 	}
 
 	if m.finished {
-		selected := m.packages[m.selectedIdx]
+		selected := m.repos[m.master]
 		return fmt.Sprintf("%s\n\n%s %s\n",
-			scannerSuccessStyle.Render("✅ Master repository selected!"),
+			scannerSuccessStyle.Render("✅ Configuration reviewed!"),
 			masterLabelStyle.Render("MASTER"),
 			scannerSuccessStyle.Render(fmt.Sprintf("%s (%s)", selected.Name, selected.Path)))
 	}
 
 	var b strings.Builder
 
-	// Title
-	b.WriteString(scannerTitleStyle.Render(m.title))
-	b.WriteString("\n")
-	b.WriteString(scannerSubtitleStyle.Render(m.subtitle))
+	b.WriteString(scannerTitleStyle.Render("🔍 Review scanned repositories"))
 	b.WriteString("\n")
-
-	if len(m.packages) == 0 {
-		b.WriteString(packageItemStyle.Render("No Dart/Flutter packages found in current directory."))
-		b.WriteString("\n")
-		b.WriteString(scannerHelpStyle.Render("Press Esc to cancel"))
-		return b.String()
+	subtitle := fmt.Sprintf("Found %d Dart/Flutter packages", len(m.repos))
+	if m.master >= 0 && m.masterReason != "" {
+		subtitle += fmt.Sprintf(" • %s is the master (%s)", m.repos[m.master].Name, m.masterReason)
 	}
+	b.WriteString(scannerSubtitleStyle.Render(subtitle))
+	b.WriteString("\n")
 
-	b.WriteString(lipgloss.NewStyle().
-		Foreground(lipgloss.Color("39")).
-		Bold(true).
-		Render("Select the master repository (main app/entry point):"))
-	b.WriteString("\n\n")
-
-	// Package list
-	for i, pkg := range m.packages {
+	for i, repo := range m.repos {
 		cursor := " "
 		if m.cursor == i {
 			cursor = "❯"
 		}
 
-		// Package icon
-		icon := "📦"
-		if strings.Contains(strings.ToLower(pkg.Name), "app") {
-			icon = "📱"
-		} else if strings.Contains(strings.ToLower(pkg.Name), "ui") {
-			icon = "🎨"
-		} else if strings.Contains(strings.ToLower(pkg.Name), "core") {
-			icon = "⚙️"
+		line := fmt.Sprintf("%s %s", cursor, repo.Name)
+		if i == m.master {
+			line += " " + masterLabelStyle.Render("MASTER")
 		}
 
-		line := fmt.Sprintf("%s %s %s", cursor, icon, pkg.Name)
-
-		// Path info
-		pathInfo := lipgloss.NewStyle().
-			Foreground(lipgloss.Color("243")).
-			Render(fmt.Sprintf("(%s)", pkg.Path))
-
-		line += " " + pathInfo
+		branch := repo.MainBranch
+		if branch == "" {
+			branch = "unknown"
+		}
+		url := repo.URL
+		if url == "" {
+			url = "no remote"
+		}
+		details := reviewDetailStyle.Render(fmt.Sprintf("%s • branch %s • %s", repo.Path, branch, url))
 
 		if m.cursor == i {
 			line = selectedPackageStyle.Render(line)
@@ -172,78 +202,46 @@ func (m ScannerModel) View() string {
 			line = packageItemStyle.Render(line)
 		}
 
-		b.WriteString(line)
-		b.WriteString("\n")
+		b.WriteString(line + "\n")
+		b.WriteString("      " + details + "\n")
+
+		if m.editing && m.cursor == i {
+			b.WriteString("      main branch: " + m.input.View() + "\n")
+		}
 	}
 
-	// Help text
 	b.WriteString("\n")
-	b.WriteString(scannerHelpStyle.Render("↑/↓ navigate • Enter select • Esc cancel"))
+	if m.editing {
+		b.WriteString(scannerHelpStyle.Render("Enter save • Esc discard"))
+	} else if m.master < 0 {
+		b.WriteString(scannerHelpStyle.Render("↑/↓ navigate • m/Space choose the master repository • e edit main branch • Esc cancel"))
+	} else {
+		b.WriteString(scannerHelpStyle.Render("↑/↓ navigate • m/Space set master • e edit main branch • Enter accept • Esc cancel"))
+	}
 
 	return b.String()
 }
 
-func (m ScannerModel) GetResult() (string, bool) {
-	if m.cancelled || !m.finished || m.selectedIdx < 0 {
-		return "", false
+// RunScanReview shows the inferred master repository and main branches so they
+// can be changed before the configuration is written. It returns the reviewed
+// repositories and the index of the master repository.
+func RunScanReview(repos []ReviewRepo, master int, masterReason string) ([]ReviewRepo, int, error) {
+	if len(repos) == 0 {
+		return nil, -1, fmt.Errorf("no packages found")
 	}
-	return m.packages[m.selectedIdx].Name, true
-}
-
-func RunPackageSelector(packages []PackageInfo) (string, error) {
-	if len(packages) == 0 {
-		return "", fmt.Errorf("no packages found")
-	}
-
-	// If only one package, auto-select it with a nice message
-	if len(packages) == 1 {
-		pkg := packages[0]
-		icon := "📦"
-		if strings.Contains(strings.ToLower(pkg.Name), "app") {
-			icon = "📱"
-		} else if strings.Contains(strings.ToLower(pkg.Name), "ui") {
-			icon = "🎨"
-		} else if strings.Contains(strings.ToLower(pkg.Name), "core") {
-			icon = "⚙️"
-		}
-
-		fmt.Printf("%s %s %s\n",
-			scannerTitleStyle.Render("🔍 Repository Scanner"),
-			scannerSubtitleStyle.Render("Found 1 Dart/Flutter package"),
-			"")
-		fmt.Printf("\n%s %s %s %s\n\n",
-			masterLabelStyle.Render("MASTER"),
-			icon,
-			scannerSuccessStyle.Render(pkg.Name),
-			lipgloss.NewStyle().Foreground(lipgloss.Color("243")).Render(fmt.Sprintf("(%s)", pkg.Path)))
-
-		return pkg.Name, nil
-	}
-
-	m := NewScanner(packages)
-	p := tea.NewProgram(m)
 
+	p := tea.NewProgram(newScanReview(repos, master, masterReason))
 	finalModel, err := p.Run()
 	if err != nil {
-		return "", fmt.Errorf("error running package selector: %w", err)
+		return nil, -1, fmt.Errorf("error running scan review: %w", err)
 	}
 
-	// Try both pointer and value types
-	if model, ok := finalModel.(*ScannerModel); ok {
-		result, success := model.GetResult()
-		if !success {
-			return "", fmt.Errorf("package selection cancelled")
-		}
-		return result, nil
+	model, ok := finalModel.(scanReviewModel)
+	if !ok {
+		return nil, -1, fmt.Errorf("unexpected model type: %T", finalModel)
 	}
-
-	if model, ok := finalModel.(ScannerModel); ok {
-		result, success := model.GetResult()
-		if !success {
-			return "", fmt.Errorf("package selection cancelled")
-		}
-		return result, nil
+	if model.cancelled || !model.finished {
+		return nil, -1, fmt.Errorf("scan review cancelled")
 	}
-
-	return "", fmt.Errorf("unexpected model type: %T", finalModel)
+	return model.repos, model.master, nil
 }
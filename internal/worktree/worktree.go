@@ -3,6 +3,8 @@ package worktree
 import (
 	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/charmbracelet/log"
 	"github.com/viniciusamelio/alfred/internal/config"
@@ -28,8 +30,66 @@ func NewManager(cfg *config.Config) *Manager {
 }
 
 func (w *Manager) GetWorktreePath(repo *config.Repository, contextName string) string {
-	// Generate path: <repo-path>-<context>
-	return fmt.Sprintf("%s-%s", repo.Path, contextName)
+	return ResolveWorktreePath(w.config.WorktreeDir, w.config.WorkspaceRoot(), repo, contextName)
+}
+
+// GetRepoPath returns the directory where a repository is checked out for a context.
+// The master repository, branch-mode contexts and the main context all use the
+// original path.
+func (w *Manager) GetRepoPath(repo *config.Repository, contextName string) string {
+	repoIdentifier := repo.Identifier()
+
+	if w.config.IsContextBranchMode(contextName) || repoIdentifier == w.config.Master ||
+		contextName == "main" || contextName == "master" {
+		return repo.Path
+	}
+	return w.GetWorktreePath(repo, contextName)
+}
+
+// ResolveWorktreePath expands a worktree_dir layout for a repository and context.
+// An empty layout keeps the legacy <repo-path>-<context> sibling directories.
+// Supported variables are {context}, {repo} (repository directory name),
+// {alias} and {project} (name of root, the workspace directory); a leading ~
+// expands to the home directory and relative layouts are resolved from root.
+func ResolveWorktreePath(layout, root string, repo *config.Repository, contextName string) string {
+	contextDir := SanitizeDirName(contextName)
+
+	if layout == "" {
+		return fmt.Sprintf("%s-%s", filepath.Clean(repo.Path), contextDir)
+	}
+
+	repoDir := filepath.Base(filepath.Clean(repo.Path))
+	alias := repo.Identifier()
+
+	path := strings.NewReplacer(
+		"{context}", contextDir,
+		"{repo}", repoDir,
+		"{alias}", SanitizeDirName(alias),
+		"{project}", filepath.Base(root),
+	).Replace(layout)
+
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			path = filepath.Join(home, strings.TrimPrefix(path, "~"))
+		}
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(root, path)
+	}
+
+	return filepath.Clean(path)
+}
+
+// SanitizeDirName turns a context or branch name into a single, flat directory name
+func SanitizeDirName(name string) string {
+	sanitized := strings.Map(func(r rune) rune {
+		switch r {
+		case '/', '\\', ':', '*', '?', '"', '<', '>', '|', ' ':
+			return '-'
+		}
+		return r
+	}, name)
+	return strings.Trim(sanitized, "-.")
 }
 
 func (w *Manager) CreateWorktreeForContext(repo *config.Repository, contextName string) (*WorktreeInfo, error) {
@@ -40,6 +100,7 @@ func (w *Manager) CreateWorktreeForContext(repo *config.Repository, contextName
 	}
 
 	worktreePath := w.GetWorktreePath(repo, contextName)
+	branchName := w.config.GetBranchName(contextName)
 
 	// Check if worktree already exists
 	worktreeExists, err := gitRepo.WorktreeExists(worktreePath)
@@ -50,8 +111,8 @@ func (w *Manager) CreateWorktreeForContext(repo *config.Repository, contextName
 	if worktreeExists {
 		w.logger.Infof("Worktree %s already exists for %s", worktreePath, repo.Alias)
 	} else {
-		w.logger.Infof("Creating worktree %s for %s with branch %s", worktreePath, repo.Alias, contextName)
-		if err := gitRepo.CreateWorktree(worktreePath, contextName); err != nil {
+		w.logger.Infof("Creating worktree %s for %s with branch %s", worktreePath, repo.Alias, branchName)
+		if err := gitRepo.CreateWorktree(worktreePath, branchName); err != nil {
 			return nil, fmt.Errorf("failed to create worktree: %w", err)
 		}
 	}
@@ -59,7 +120,7 @@ func (w *Manager) CreateWorktreeForContext(repo *config.Repository, contextName
 	return &WorktreeInfo{
 		Repo:         repo,
 		WorktreePath: worktreePath,
-		BranchName:   contextName,
+		BranchName:   branchName,
 	}, nil
 }
 
@@ -93,7 +154,7 @@ func (w *Manager) ListWorktreesForContext(repos []*config.Repository, contextNam
 			worktrees = append(worktrees, &WorktreeInfo{
 				Repo:         repo,
 				WorktreePath: worktreePath,
-				BranchName:   contextName,
+				BranchName:   w.config.GetBranchName(contextName),
 			})
 		}
 	}
@@ -175,3 +236,87 @@ func (w *Manager) GetWorktreeStatus(worktree *WorktreeInfo) (string, error) {
 
 	return worktree.BranchName, nil
 }
+
+// MigrateWorktreeForContext moves an existing worktree from the path produced by
+// fromLayout to the currently configured layout using git worktree move.
+// It returns the old and new paths, and whether a move was (or would be) made.
+func (w *Manager) MigrateWorktreeForContext(repo *config.Repository, contextName, fromLayout string, dryRun bool) (string, string, bool, error) {
+	gitRepo := git.NewGitRepo(repo.Path)
+	oldPath := ResolveWorktreePath(fromLayout, w.config.WorkspaceRoot(), repo, contextName)
+	newPath := w.GetWorktreePath(repo, contextName)
+
+	oldAbs, err := filepath.Abs(oldPath)
+	if err != nil {
+		return oldPath, newPath, false, fmt.Errorf("failed to get absolute path: %w", err)
+	}
+	newAbs, err := filepath.Abs(newPath)
+	if err != nil {
+		return oldPath, newPath, false, fmt.Errorf("failed to get absolute path: %w", err)
+	}
+	if oldAbs == newAbs {
+		return oldPath, newPath, false, nil
+	}
+
+	oldExists, err := gitRepo.WorktreeExists(oldPath)
+	if err != nil {
+		return oldPath, newPath, false, fmt.Errorf("failed to check worktree existence: %w", err)
+	}
+	if !oldExists {
+		return oldPath, newPath, false, nil
+	}
+
+	if _, err := os.Stat(newPath); err == nil {
+		return oldPath, newPath, false, fmt.Errorf("target %s already exists", newPath)
+	}
+
+	if dryRun {
+		return oldPath, newPath, true, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(newAbs), 0755); err != nil {
+		return oldPath, newPath, false, fmt.Errorf("failed to create parent directory: %w", err)
+	}
+
+	repoIdentifier := repo.Identifier()
+
+	w.logger.Infof("Moving worktree for %s from %s to %s", repoIdentifier, oldPath, newPath)
+	if err := gitRepo.MoveWorktree(oldPath, newPath); err != nil {
+		return oldPath, newPath, false, err
+	}
+
+	return oldPath, newPath, true, nil
+}
+
+// CheckWorktreePaths fails when two worktrees of the given contexts resolve to
+// the same directory, like a {repo} layout does for repositories sharing a
+// directory name
+func (w *Manager) CheckWorktreePaths(contexts []string) error {
+	owners := make(map[string]string)
+	var conflicts []string
+
+	for _, contextName := range contexts {
+		repos, err := w.config.GetNonMasterReposForContext(contextName)
+		if err != nil {
+			return err
+		}
+		for _, repo := range repos {
+			path, err := filepath.Abs(w.GetWorktreePath(repo, contextName))
+			if err != nil {
+				return fmt.Errorf("failed to get absolute path: %w", err)
+			}
+
+			owner := fmt.Sprintf("%s/%s", contextName, repo.Identifier())
+			if previous, taken := owners[path]; taken {
+				conflicts = append(conflicts, fmt.Sprintf("%s and %s both use %s", previous, owner, path))
+				continue
+			}
+			owners[path] = owner
+		}
+	}
+
+	if len(conflicts) > 0 {
+		return fmt.Errorf("worktree_dir does not give every worktree its own directory, add {alias} or {context} to it: %s",
+			strings.Join(conflicts, "; "))
+	}
+	return nil
+}
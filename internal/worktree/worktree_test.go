@@ -0,0 +1,89 @@
+package worktree
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/viniciusamelio/alfred/internal/config"
+	"github.com/viniciusamelio/alfred/internal/git"
+	"github.com/viniciusamelio/alfred/internal/testutil"
+)
+
+func TestManager_MigrateWorktreeForContext(t *testing.T) {
+	testutil.SetGitIdentity(t)
+
+	workspace := t.TempDir()
+	testutil.Chdir(t, workspace)
+
+	// Two repositories checked out in directories with the same name
+	var repos []config.Repository
+	for _, name := range []string{"mobile", "web"} {
+		path := filepath.Join(workspace, name, "core")
+		testutil.InitRepo(t, path, "main", nil)
+		testutil.RunGit(t, path, "worktree", "add", "-q", "-b", "login", path+"-login")
+		repos = append(repos, config.Repository{Name: "core", Alias: name, Path: path})
+	}
+
+	cfg := &config.Config{
+		Repos:      repos,
+		Mode:       config.ModeWorktree,
+		MainBranch: "main",
+		Contexts:   map[string][]string{"login": {"mobile", "web"}},
+	}
+	w := NewManager(cfg)
+
+	cfg.WorktreeDir = "worktrees/{context}/{repo}"
+	if err := w.CheckWorktreePaths([]string{"login"}); err == nil {
+		t.Error("Expected a layout giving both repositories the same worktree to be rejected")
+	}
+
+	cfg.WorktreeDir = "worktrees/{context}/{alias}"
+	if err := w.CheckWorktreePaths([]string{"login"}); err != nil {
+		t.Fatal(err)
+	}
+
+	repo := &cfg.Repos[0]
+	oldPath, newPath, migrated, err := w.MigrateWorktreeForContext(repo, "login", "", true)
+	if err != nil || !migrated {
+		t.Fatalf("Expected a dry run to report the move, got %v (%v)", migrated, err)
+	}
+	if _, err := os.Stat(newPath); !os.IsNotExist(err) {
+		t.Errorf("Expected a dry run to leave %s alone, got %v", newPath, err)
+	}
+
+	if _, _, migrated, err := w.MigrateWorktreeForContext(repo, "login", "", false); err != nil || !migrated {
+		t.Fatalf("Expected the worktree to be moved, got %v (%v)", migrated, err)
+	}
+	if exists, err := git.NewGitRepo(repo.Path).WorktreeExists(newPath); err != nil || !exists {
+		t.Errorf("Expected a worktree at %s, got %v (%v)", newPath, exists, err)
+	}
+	if _, err := os.Stat(oldPath); !os.IsNotExist(err) {
+		t.Errorf("Expected %s to be gone, got %v", oldPath, err)
+	}
+
+	// Moving again finds nothing at the old path
+	if _, _, migrated, err := w.MigrateWorktreeForContext(repo, "login", "", false); err != nil || migrated {
+		t.Errorf("Expected nothing left to migrate, got %v (%v)", migrated, err)
+	}
+}
+
+func TestResolveWorktreePath(t *testing.T) {
+	// The workspace root decides the paths, wherever alfred runs from
+	testutil.Chdir(t, t.TempDir())
+
+	repo := &config.Repository{Name: "core", Alias: "kernel", Path: "/work/mobile/core"}
+	tests := []struct {
+		layout   string
+		expected string
+	}{
+		{"", "/work/mobile/core-feature-login"},
+		{"../{project}-worktrees/{context}/{alias}", "/work/mobile-worktrees/feature-login/kernel"},
+		{"/tmp/{project}/{repo}-{context}", "/tmp/mobile/core-feature-login"},
+	}
+	for _, test := range tests {
+		if path := ResolveWorktreePath(test.layout, "/work/mobile", repo, "feature/login"); path != test.expected {
+			t.Errorf("ResolveWorktreePath(%q) = %s, expected %s", test.layout, path, test.expected)
+		}
+	}
+}